@@ -0,0 +1,218 @@
+package rosetta
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/syndtr/goleveldb/leveldb"
+
+	bank "cosmossdk.io/x/bank/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+// cache names and lookup results label values
+const (
+	cacheBlocks = "blocks"
+	cacheTxs    = "txs"
+
+	cacheHit     = "hit"
+	cacheDiskHit = "disk_hit"
+	cacheMiss    = "miss"
+)
+
+// cacheVersion is the version of the cached responses, it must be increased when the
+// responses change so that the ones cached on disk by previous versions are not served
+const cacheVersion = 1
+
+var cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rosetta",
+	Subsystem: "cache",
+	Name:      "lookups_total",
+	Help:      "Number of lookups of the block and transaction caches by cache and result.",
+}, []string{"cache", "result"})
+
+// responseCache caches the block and transaction responses which can no longer change.
+// CometBFT has instant finality, so every block below the tip of the chain is immutable.
+// Responses are kept in a size bounded LRU and, if a directory is provided, on disk
+// so that they survive restarts. The cached responses are shared, they must not be modified.
+type responseCache struct {
+	blocks *lru.Cache[string, crgtypes.BlockTransactionsResponse]
+	txs    *lru.Cache[string, *rosettatypes.Transaction]
+	// disk is nil if the disk cache is disabled
+	disk *leveldb.DB
+	// diskPrefix prefixes the disk keys with the cache version and configuration
+	diskPrefix string
+
+	// latestHeight is the last known height of the tip of the chain
+	latestHeight atomic.Int64
+}
+
+// newResponseCache builds a cache holding at most size blocks and size transactions in memory,
+// the on disk cache is stored in dir, it is disabled if dir is empty. The responses cached on
+// disk by another version of the cache or with another config are not served.
+func newResponseCache(size int, dir, config string) (*responseCache, error) {
+	blocks, err := lru.New[string, crgtypes.BlockTransactionsResponse](size)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("creating blocks cache %s", err.Error()))
+	}
+	txs, err := lru.New[string, *rosettatypes.Transaction](size)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("creating txs cache %s", err.Error()))
+	}
+
+	configHash := sha256.Sum256([]byte(config))
+	cache := &responseCache{blocks: blocks, txs: txs, diskPrefix: fmt.Sprintf("v%d/%x/", cacheVersion, configHash[:8])}
+	if dir != "" {
+		cache.disk, err = leveldb.OpenFile(dir, nil)
+		if err != nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("opening disk cache %s", err.Error()))
+		}
+	}
+	return cache, nil
+}
+
+// cacheConfig returns the configuration the cached responses depend on
+func cacheConfig(cfg *Config, denomMetadata []bank.Metadata) string {
+	metadata, _ := json.Marshal(denomMetadata)
+	return fmt.Sprintf("%s/%t/%s", cfg.Bech32Prefix, cfg.RawOperationTypes, metadata)
+}
+
+func blockHeightKey(height int64) string {
+	return fmt.Sprintf("block/height/%d", height)
+}
+
+func blockHashKey(hash string) string {
+	return "block/hash/" + strings.ToUpper(hash)
+}
+
+func txKey(hash string) string {
+	return "tx/" + strings.ToUpper(hash)
+}
+
+// immutable reports whether the block at the given height is below the last known tip
+func (c *responseCache) immutable(height int64) bool {
+	return height < c.latestHeight.Load()
+}
+
+// observeHeight records the height of a block seen at the tip of the chain
+func (c *responseCache) observeHeight(height int64) {
+	for {
+		latest := c.latestHeight.Load()
+		if height <= latest || c.latestHeight.CompareAndSwap(latest, height) {
+			return
+		}
+	}
+}
+
+// block returns the cached block response stored at key
+func (c *responseCache) block(key string) (crgtypes.BlockTransactionsResponse, bool) {
+	if block, ok := c.blocks.Get(key); ok {
+		cacheLookups.WithLabelValues(cacheBlocks, cacheHit).Inc()
+		return block, true
+	}
+	var block crgtypes.BlockTransactionsResponse
+	if c.diskGet(key, &block) {
+		cacheLookups.WithLabelValues(cacheBlocks, cacheDiskHit).Inc()
+		c.addBlock(block, false)
+		return block, true
+	}
+	cacheLookups.WithLabelValues(cacheBlocks, cacheMiss).Inc()
+	return crgtypes.BlockTransactionsResponse{}, false
+}
+
+// addBlock caches the block response by height and hash,
+// persist defines whether the block is also written to disk
+func (c *responseCache) addBlock(block crgtypes.BlockTransactionsResponse, persist bool) {
+	keys := []string{blockHeightKey(block.Block.Index), blockHashKey(block.Block.Hash)}
+	for _, key := range keys {
+		c.blocks.Add(key, block)
+		if persist {
+			c.diskPut(key, block)
+		}
+	}
+}
+
+// tx returns the cached transaction with the given hash
+func (c *responseCache) tx(hash string) (*rosettatypes.Transaction, bool) {
+	key := txKey(hash)
+	if tx, ok := c.txs.Get(key); ok {
+		cacheLookups.WithLabelValues(cacheTxs, cacheHit).Inc()
+		return tx, true
+	}
+	tx := new(rosettatypes.Transaction)
+	if c.diskGet(key, tx) {
+		cacheLookups.WithLabelValues(cacheTxs, cacheDiskHit).Inc()
+		c.txs.Add(key, tx)
+		return tx, true
+	}
+	cacheLookups.WithLabelValues(cacheTxs, cacheMiss).Inc()
+	return nil, false
+}
+
+// addTx caches the transaction by hash
+func (c *responseCache) addTx(hash string, tx *rosettatypes.Transaction) {
+	key := txKey(hash)
+	c.txs.Add(key, tx)
+	c.diskPut(key, tx)
+}
+
+func currencyKey(denom string) string {
+	return "currency/" + denom
+}
+
+// currency returns the currency of denom stored on disk, the currencies are not kept in memory
+// as the denom registry keeps the currencies it served
+func (c *responseCache) currency(denom string) (*rosettatypes.Currency, bool) {
+	currency := new(rosettatypes.Currency)
+	if !c.diskGet(currencyKey(denom), currency) {
+		return nil, false
+	}
+	return currency, true
+}
+
+// addCurrency stores the currency on disk
+func (c *responseCache) addCurrency(currency *rosettatypes.Currency) {
+	c.diskPut(currencyKey(currency.Symbol), currency)
+}
+
+// diskGet decodes the value stored on disk at key into v, it reports whether the value was found.
+// Disk errors are not fatal to the cache and are treated as misses.
+func (c *responseCache) diskGet(key string, v interface{}) bool {
+	if c.disk == nil {
+		return false
+	}
+	b, err := c.disk.Get([]byte(c.diskPrefix+key), nil)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(b, v) == nil
+}
+
+// diskPut stores v on disk at key, failing to write to disk only costs a future miss
+func (c *responseCache) diskPut(key string, v interface{}) {
+	if c.disk == nil {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	_ = c.disk.Put([]byte(c.diskPrefix+key), b, nil)
+}
+
+// Close closes the on disk cache
+func (c *responseCache) Close() error {
+	if c.disk == nil {
+		return nil
+	}
+	return c.disk.Close()
+}
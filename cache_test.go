@@ -0,0 +1,81 @@
+package rosetta
+
+import (
+	"testing"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/require"
+
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+func TestResponseCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := newResponseCache(2, dir, "config")
+	require.NoError(t, err)
+
+	block := crgtypes.BlockTransactionsResponse{
+		BlockResponse: crgtypes.BlockResponse{
+			Block:       &rosettatypes.BlockIdentifier{Index: 10, Hash: "ABCD"},
+			ParentBlock: &rosettatypes.BlockIdentifier{Index: 9, Hash: "0123"},
+			TxCount:     1,
+		},
+		Transactions: []*rosettatypes.Transaction{{TransactionIdentifier: &rosettatypes.TransactionIdentifier{Hash: "EF01"}}},
+	}
+	tx := block.Transactions[0]
+
+	_, ok := cache.block(blockHeightKey(10))
+	require.False(t, ok)
+
+	cache.addBlock(block, true)
+	cache.addTx("ef01", tx)
+
+	got, ok := cache.block(blockHeightKey(10))
+	require.True(t, ok)
+	require.Equal(t, block, got)
+	// hashes are case insensitive
+	got, ok = cache.block(blockHashKey("abcd"))
+	require.True(t, ok)
+	require.Equal(t, block, got)
+	gotTx, ok := cache.tx("EF01")
+	require.True(t, ok)
+	require.Equal(t, tx, gotTx)
+
+	// the entries survive a restart through the disk cache
+	require.NoError(t, cache.Close())
+	cache, err = newResponseCache(2, dir, "config")
+	require.NoError(t, err)
+
+	got, ok = cache.block(blockHashKey("ABCD"))
+	require.True(t, ok)
+	require.Equal(t, block, got)
+	gotTx, ok = cache.tx("EF01")
+	require.True(t, ok)
+	require.Equal(t, tx, gotTx)
+
+	// the entries cached with another config are not served
+	require.NoError(t, cache.Close())
+	cache, err = newResponseCache(2, dir, "other")
+	require.NoError(t, err)
+	defer cache.Close()
+
+	_, ok = cache.block(blockHashKey("ABCD"))
+	require.False(t, ok)
+	_, ok = cache.tx("EF01")
+	require.False(t, ok)
+}
+
+func TestResponseCache_immutable(t *testing.T) {
+	cache, err := newResponseCache(1, "", "config")
+	require.NoError(t, err)
+
+	require.False(t, cache.immutable(1))
+
+	cache.observeHeight(10)
+	require.True(t, cache.immutable(9))
+	require.False(t, cache.immutable(10), "the tip is not immutable")
+
+	// a lower height does not move the tip back
+	cache.observeHeight(5)
+	require.True(t, cache.immutable(9))
+}
@@ -0,0 +1,119 @@
+package rosetta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	gogoproto "github.com/cosmos/gogoproto/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	// registers the staking query types served by /call
+	_ "cosmossdk.io/x/staking/types"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// defaultCallMethods are the gRPC queries served by /call, only the ones
+// whose types are known to the interface registry of the network are served
+var defaultCallMethods = []string{
+	"cosmos.auth.v1beta1.Query/Account",
+	"cosmos.auth.v1beta1.Query/AccountInfo",
+	"cosmos.staking.v1beta1.Query/DelegatorDelegations",
+	"cosmos.staking.v1beta1.Query/DelegatorUnbondingDelegations",
+	"cosmos.staking.v1beta1.Query/Validator",
+	"cosmos.staking.v1beta1.Query/Validators",
+	"cosmos.base.tendermint.v1beta1.Service/GetLatestValidatorSet",
+	"cosmos.base.tendermint.v1beta1.Service/GetValidatorSetByHeight",
+	"cosmos.bank.v1beta1.Query/DenomMetadata",
+	"cosmos.bank.v1beta1.Query/DenomsMetadata",
+}
+
+// callMethod holds the request and response types of a gRPC query served by /call
+type callMethod struct {
+	request  reflect.Type
+	response reflect.Type
+}
+
+// resolveCallMethods returns the methods whose service is known to the interface registry and
+// whose request and response types are registered, keyed by method name
+func resolveCallMethods(ir codectypes.InterfaceRegistry, methods []string) map[string]callMethod {
+	resolved := make(map[string]callMethod, len(methods))
+	for _, name := range methods {
+		service, method, ok := strings.Cut(name, "/")
+		if !ok {
+			continue
+		}
+		desc, err := ir.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			continue
+		}
+		serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		methodDesc := serviceDesc.Methods().ByName(protoreflect.Name(method))
+		if methodDesc == nil || methodDesc.IsStreamingClient() || methodDesc.IsStreamingServer() {
+			continue
+		}
+		request := gogoproto.MessageType(string(methodDesc.Input().FullName()))
+		response := gogoproto.MessageType(string(methodDesc.Output().FullName()))
+		if request == nil || response == nil {
+			continue
+		}
+		resolved[name] = callMethod{request: request.Elem(), response: response.Elem()}
+	}
+	return resolved
+}
+
+// CallMethods returns the gRPC queries served by /call
+func (c *Client) CallMethods() []string {
+	methods := make([]string, 0, len(c.callMethods))
+	for _, method := range defaultCallMethods {
+		if _, ok := c.callMethods[method]; ok {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// Call invokes the gRPC query method at the latest height, the parameters are the JSON
+// encoding of the query request and the result is the JSON encoding of the query response
+func (c *Client) Call(ctx context.Context, method string, parameters map[string]interface{}) (map[string]interface{}, error) {
+	m, ok := c.callMethods[method]
+	if !ok {
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("unsupported call method %s", method))
+	}
+
+	bz, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("encoding parameters %s", err.Error()))
+	}
+	request := reflect.New(m.request).Interface().(gogoproto.Message)
+	if err := c.config.Codec.UnmarshalJSON(bz, request); err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("invalid parameters of %s %s", method, err.Error()))
+	}
+
+	response := reflect.New(m.response).Interface().(gogoproto.Message)
+	n, err := c.node()
+	if err != nil {
+		return nil, err
+	}
+	if err := n.invoker.Invoke(ctx, "/"+method, request, response); err != nil {
+		return nil, crgerrs.FromGRPCToRosettaError(err)
+	}
+
+	bz, err = c.config.Codec.MarshalJSON(response)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrCodec, fmt.Sprintf("encoding response of %s %s", method, err.Error()))
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(bz, &result); err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrCodec, fmt.Sprintf("decoding response of %s %s", method, err.Error()))
+	}
+	return result, nil
+}
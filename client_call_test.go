@@ -0,0 +1,57 @@
+package rosetta
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	bank "cosmossdk.io/x/bank/types"
+)
+
+// denomMetadataInvoker serves the bank denom metadata query
+type denomMetadataInvoker struct {
+	grpc.ClientConnInterface
+}
+
+func (denomMetadataInvoker) Invoke(_ context.Context, method string, args, reply interface{}, _ ...grpc.CallOption) error {
+	if method != "/cosmos.bank.v1beta1.Query/DenomMetadata" {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	req := args.(*bank.QueryDenomMetadataRequest)
+	reply.(*bank.QueryDenomMetadataResponse).Metadata = bank.Metadata{Base: req.Denom, Display: "atom"}
+	return nil
+}
+
+func TestClient_Call(t *testing.T) {
+	client := newTestClient(t, &Config{}, &node{invoker: denomMetadataInvoker{}})
+	ctx := context.Background()
+
+	require.Contains(t, client.CallMethods(), "cosmos.auth.v1beta1.Query/Account")
+	require.Contains(t, client.CallMethods(), "cosmos.staking.v1beta1.Query/Validators")
+
+	result, err := client.Call(ctx, "cosmos.bank.v1beta1.Query/DenomMetadata", map[string]interface{}{"denom": "uatom"})
+	require.NoError(t, err)
+	require.Equal(t, "uatom", result["metadata"].(map[string]interface{})["base"])
+	require.Equal(t, "atom", result["metadata"].(map[string]interface{})["display"])
+
+	// only the whitelisted queries are served
+	_, err = client.Call(ctx, "cosmos.bank.v1beta1.Query/Balance", nil)
+	require.Error(t, err)
+	_, err = client.Call(ctx, "cosmos.bank.v1beta1.Query/DenomMetadata", map[string]interface{}{"unknown": "uatom"})
+	require.Error(t, err)
+}
+
+func TestResolveCallMethods(t *testing.T) {
+	_, ir := MakeCodec()
+	methods := resolveCallMethods(ir, []string{
+		"cosmos.bank.v1beta1.Query/DenomMetadata",
+		"cosmos.bank.v1beta1.Query/Unknown",
+		"cosmos.unknown.v1beta1.Query/Method",
+		"invalid",
+	})
+	require.Len(t, methods, 1)
+	require.Equal(t, "QueryDenomMetadataRequest", methods["cosmos.bank.v1beta1.Query/DenomMetadata"].request.Name())
+}
@@ -7,8 +7,6 @@ import (
 
 	"github.com/coinbase/rosetta-sdk-go/types"
 
-	sdk "github.com/cosmos/cosmos-sdk/types"
-
 	crgerrs "github.com/cosmos/rosetta/lib/errors"
 )
 
@@ -35,6 +33,18 @@ func (c *Client) SupportedOperations() []string {
 	return c.supportedOperations
 }
 
+// BalanceExemptions returns the sub accounts whose balance changes without any operation: the staking
+// sub accounts change as the rewards accrue, the unbondings complete and the validators are slashed,
+// the vesting ones as the vesting schedules unlock tokens over time
+func (c *Client) BalanceExemptions() []*types.BalanceExemption {
+	subAccounts := []string{SubAccountStaking, SubAccountUnbonding, SubAccountRewards, SubAccountLocked, SubAccountSpendable}
+	exemptions := make([]*types.BalanceExemption, len(subAccounts))
+	for i := range subAccounts {
+		exemptions[i] = &types.BalanceExemption{SubAccountAddress: &subAccounts[i], ExemptionType: types.BalanceDynamic}
+	}
+	return exemptions
+}
+
 // ---------- cosmos-rosetta-gateway.types.OfflineClient implementation ------------ //
 
 func (c *Client) SignedTx(_ context.Context, txBytes []byte, signatures []*types.Signature) (signedTxBytes []byte, err error) {
@@ -89,10 +99,13 @@ func (c *Client) PreprocessOperationsToOptions(_ context.Context, req *types.Con
 	accountIdentifiers := make([]*types.AccountIdentifier, len(signers))
 
 	for i, sig := range signers {
-		addr := sdk.AccAddress(sig)
-		signersStr[i] = addr.String()
+		addr, err := c.addressCodec.BytesToString(sig)
+		if err != nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrInvalidAddress, fmt.Sprintf("converting signer address %s", err.Error()))
+		}
+		signersStr[i] = addr
 		accountIdentifiers[i] = &types.AccountIdentifier{
-			Address: addr.String(),
+			Address: addr,
 		}
 	}
 	// get the metadata request information
@@ -128,13 +141,18 @@ func (c *Client) PreprocessOperationsToOptions(_ context.Context, req *types.Con
 	}, nil
 }
 
-func (c *Client) AccountIdentifierFromPublicKey(pubKey *types.PublicKey) (*types.AccountIdentifier, error) {
+func (c *Client) AccountIdentifierFromPublicKey(_ context.Context, pubKey *types.PublicKey) (*types.AccountIdentifier, error) {
 	pk, err := c.converter.ToSDK().PubKey(pubKey)
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrConverter, fmt.Sprintf("converting pub key to sdk %s", err.Error()))
 	}
 
+	addr, err := c.addressCodec.BytesToString(pk.Address())
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrInvalidAddress, fmt.Sprintf("converting pub key address %s", err.Error()))
+	}
+
 	return &types.AccountIdentifier{
-		Address: sdk.AccAddress(pk.Address()).String(),
+		Address: addr,
 	}, nil
 }
@@ -12,12 +12,11 @@ import (
 
 	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
 	abcitypes "github.com/cometbft/cometbft/abci/types"
-	tmrpc "github.com/cometbft/cometbft/rpc/client"
-	"github.com/cometbft/cometbft/rpc/client/http"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc/metadata"
 
+	coreaddress "cosmossdk.io/core/address"
+	sdkmath "cosmossdk.io/math"
 	bank "cosmossdk.io/x/bank/types"
 
 	"github.com/cosmos/cosmos-sdk/codec/address"
@@ -33,25 +32,46 @@ import (
 )
 
 // interface assertion
-var _ crgtypes.Client = (*Client)(nil)
+var (
+	_ crgtypes.Client        = (*Client)(nil)
+	_ crgtypes.HealthChecker = (*Client)(nil)
+	_ crgtypes.Reconnector   = (*Client)(nil)
+)
 
 const (
 	defaultNodeTimeout = time.Minute
 )
 
+// node components reported by Health
+const (
+	HealthComponentCometBFT = "cometbft"
+	HealthComponentGRPC     = "grpc"
+	HealthComponentSync     = crgtypes.SyncComponent
+)
+
 // Client implements a single network client to interact with cosmos based chains
 type Client struct {
 	supportedOperations []string
 
 	config *Config
 
-	auth  auth.QueryClient
-	bank  bank.QueryClient
-	tmRPC tmrpc.Client
+	// pool holds the connections to the nodes, they are nil until the client is bootstrapped
+	// and they are replaced when the client reconnects
+	pool *nodePool
 
 	version string
 
 	converter Converter
+	// addressCodec encodes addresses with the bech32 prefix of the network
+	addressCodec coreaddress.Codec
+	// cache holds the immutable block and transaction responses, nil if disabled
+	cache *responseCache
+	// events maps the /events/blocks sequences to block heights
+	events *blockEvents
+	// callMethods are the gRPC queries served by /call
+	callMethods map[string]callMethod
+	// denoms holds the metadata of the denoms filling the currencies
+	denoms *denomRegistry
 }
 
 // NewClient instantiates a new online servicer
@@ -89,15 +109,65 @@ func NewClient(cfg *Config) (*Client, error) {
 		bank.EventTypeCoinReceived,
 		bank.EventTypeCoinBurn,
 	)
+	if !cfg.RawOperationTypes {
+		supportedOperations = append(supportedOperations, OperationFee)
+		supportedOperations = append(supportedOperations, semanticOperationTypes...)
+	}
+
+	transport, err := cfg.nodeTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	denomMetadata, err := loadDenomMetadataFile(cfg.DenomMetadataFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache *responseCache
+	if cfg.CacheSize > 0 {
+		cache, err = newResponseCache(cfg.CacheSize, cfg.cacheDir(), cacheConfig(cfg, denomMetadata))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the block events are not served if their sequences cannot be persisted
+	var events *blockEvents
+	if dir := cfg.eventsDir(); dir != "" {
+		events, err = newBlockEvents(dir)
+		if err != nil {
+			if cache != nil {
+				_ = cache.Close()
+			}
+			return nil, err
+		}
+	}
+
+	pool := newNodePool(cfg.nodeEndpoints(), cfg.archiveEndpoints(), cfg.PruningWindow, transport)
+	traces, err := newDenomTraces(cfg.denomTracesDir())
+	if err != nil {
+		if cache != nil {
+			_ = cache.Close()
+		}
+		if events != nil {
+			_ = events.Close()
+		}
+		return nil, err
+	}
+	denoms := newDenomRegistry(denomMetadata, traces, cache)
 
 	return &Client{
 		supportedOperations: supportedOperations,
 		config:              cfg,
-		auth:                nil,
-		bank:                nil,
-		tmRPC:               nil,
+		pool:                pool,
 		version:             fmt.Sprintf("%s/%s", info.AppName, v),
-		converter:           NewConverter(cfg.Codec, cfg.InterfaceRegistry, txConfig, address.NewBech32Codec(cfg.Bech32Prefix)),
+		converter:           newConverter(cfg.Codec, cfg.InterfaceRegistry, txConfig, address.NewBech32Codec(cfg.Bech32Prefix), denoms, cfg.RawOperationTypes),
+		addressCodec:        ac,
+		cache:               cache,
+		events:              events,
+		callMethods:         resolveCallMethods(cfg.InterfaceRegistry, defaultCallMethods),
+		denoms:              denoms,
 	}, nil
 }
 
@@ -105,45 +175,103 @@ func NewClient(cfg *Config) (*Client, error) {
 
 // Bootstrap is gonna connect the client to the endpoints
 func (c *Client) Bootstrap() error {
-	grpcConn, err := grpc.NewClient(c.config.GRPCEndpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("dialing grpc endpoint %s", err.Error()))
-	}
+	return c.Reconnect()
+}
 
-	tmRPC, err := http.New(c.config.TendermintRPC)
-	if err != nil {
-		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting rpc path %s", err.Error()))
-	}
+// Reconnect opens new connections to the nodes which are not connected or did not respond to
+// their last check, and closes their current ones. The requests in flight on the closed
+// connections are interrupted, the healthy nodes keep their connections.
+func (c *Client) Reconnect() error {
+	return c.pool.dial()
+}
+
+// node returns the connections to the node selected to serve the requests,
+// an error is returned if the client is not connected or was closed
+func (c *Client) node() (*node, error) {
+	return connected(c.pool.node())
+}
 
-	authClient := auth.NewQueryClient(grpcConn)
-	bankClient := bank.NewQueryClient(grpcConn)
+// nodeAt returns the connections to a node having the state at height,
+// requests depending on a height must use the same node for all their queries
+func (c *Client) nodeAt(height *int64) (*node, error) {
+	return connected(c.pool.nodeAt(height))
+}
 
-	c.auth = authClient
-	c.bank = bankClient
-	c.tmRPC = tmRPC
+// connected returns an error if the node connections are not set
+func connected(n *node) (*node, error) {
+	if n == nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, "client is not connected to the node")
+	}
+	return n, nil
+}
+
+// Close releases the connections to the node opened by Bootstrap, the response cache, the events
+// store and the denom traces store. It is safe to call Close on a client which was never bootstrapped.
+func (c *Client) Close() error {
+	var cacheErr, eventsErr, tracesErr error
+	if c.cache != nil {
+		cacheErr = c.cache.Close()
+		c.cache = nil
+	}
+	if c.events != nil {
+		eventsErr = c.events.Close()
+	}
+	if c.denoms.traces != nil {
+		tracesErr = c.denoms.traces.Close()
+	}
 
+	if err := c.pool.close(); err != nil {
+		return err
+	}
+	if cacheErr != nil {
+		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("closing cache %s", cacheErr.Error()))
+	}
+	if eventsErr != nil {
+		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("closing events store %s", eventsErr.Error()))
+	}
+	if tracesErr != nil {
+		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("closing denom traces store %s", tracesErr.Error()))
+	}
 	return nil
 }
 
-// Ready performs a health check and returns an error if the client is not ready.
+// Ready checks the nodes and returns an error if the selected node is not reachable. The client
+// is not ready until the denom metadata is loaded, so that the currencies never miss their decimals,
+// unless metadata is configured: the metadata of the chain is then loaded by the next health checks.
 func (c *Client) Ready() error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultNodeTimeout)
 	defer cancel()
-	_, err := c.tmRPC.Health(ctx)
-	if err != nil {
-		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting grpc health %s", err.Error()))
+	status := c.pool.refresh(ctx)
+	if status.reachable {
+		if c.denoms.stale(c.config.DenomMetadataRefresh) {
+			if err := c.refreshDenoms(ctx); err != nil && !c.denoms.hasOverrides() {
+				return err
+			}
+		}
+		return nil
 	}
-
-	_, err = c.tmRPC.Status(ctx)
-	if err != nil {
-		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting grpc status %s", err.Error()))
+	for _, component := range []string{HealthComponentCometBFT, HealthComponentGRPC, HealthComponentSync} {
+		if err := status.health[component]; err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	_, err = c.bank.TotalSupply(ctx, &bank.QueryTotalSupplyRequest{})
-	if err != nil {
-		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting bank total supply %s", err.Error()))
+// Health checks every node, fails over to another node if the selected one stopped responding or
+// fell behind, then reports separately the health of the CometBFT RPC, of the application gRPC
+// and whether the selected node is synced, a node which is catching up is reported as unhealthy.
+// The denom metadata is refreshed along the checks once older than the refresh interval.
+func (c *Client) Health(ctx context.Context) map[string]error {
+	status := c.pool.refresh(ctx)
+	if status.reachable {
+		c.observeTip(status.latestHeight)
+		if c.denoms.stale(c.config.DenomMetadataRefresh) {
+			// a failed refresh keeps the previous metadata and is retried at the next check
+			_ = c.refreshDenoms(ctx)
+		}
 	}
-	return nil
+	return status.health
 }
 
 func (c *Client) GenesisBlock(ctx context.Context) (crgtypes.BlockResponse, error) {
@@ -152,7 +280,11 @@ func (c *Client) GenesisBlock(ctx context.Context) (crgtypes.BlockResponse, erro
 }
 
 func (c *Client) InitialHeightBlock(ctx context.Context) (crgtypes.BlockResponse, error) {
-	genesisChunk, err := c.tmRPC.GenesisChunked(ctx, 0)
+	n, err := c.node()
+	if err != nil {
+		return crgtypes.BlockResponse{}, err
+	}
+	genesisChunk, err := n.tmRPC.GenesisChunked(ctx, 0)
 	if err != nil {
 		return crgtypes.BlockResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting bank total supply %s", err.Error()))
 	}
@@ -163,12 +295,32 @@ func (c *Client) InitialHeightBlock(ctx context.Context) (crgtypes.BlockResponse
 	return c.BlockByHeight(ctx, &heightNum)
 }
 
+// OldestBlock returns the oldest block kept by the archive node, or by the
+// selected node if the archive node is not configured or unavailable
 func (c *Client) OldestBlock(ctx context.Context) (crgtypes.BlockResponse, error) {
-	status, err := c.tmRPC.Status(ctx)
+	if archive := c.pool.archiveNode(); archive != nil {
+		if block, err := c.oldestBlock(ctx, archive); err == nil {
+			return block, nil
+		}
+	}
+	n, err := c.node()
+	if err != nil {
+		return crgtypes.BlockResponse{}, err
+	}
+	return c.oldestBlock(ctx, n)
+}
+
+// oldestBlock returns the oldest block kept by the node
+func (c *Client) oldestBlock(ctx context.Context, n *node) (crgtypes.BlockResponse, error) {
+	status, err := n.tmRPC.Status(ctx)
 	if err != nil {
 		return crgtypes.BlockResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting oldest block %s", err.Error()))
 	}
-	return c.BlockByHeight(ctx, &status.SyncInfo.EarliestBlockHeight)
+	block, err := n.tmRPC.Block(ctx, &status.SyncInfo.EarliestBlockHeight)
+	if err != nil {
+		return crgtypes.BlockResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting block by height %s", err.Error()))
+	}
+	return c.converter.ToRosetta().BlockResponse(block, nil, nil), nil
 }
 
 func (c *Client) accountInfo(ctx context.Context, addr string, height *int64) (*SignerData, error) {
@@ -177,7 +329,11 @@ func (c *Client) accountInfo(ctx context.Context, addr string, height *int64) (*
 		ctx = metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strHeight)
 	}
 
-	accountInfo, err := c.auth.Account(ctx, &auth.QueryAccountRequest{
+	n, err := c.nodeAt(height)
+	if err != nil {
+		return nil, err
+	}
+	accountInfo, err := n.auth.Account(ctx, &auth.QueryAccountRequest{
 		Address: addr,
 	})
 	if err != nil {
@@ -191,25 +347,76 @@ func (c *Client) accountInfo(ctx context.Context, addr string, height *int64) (*
 	return signerData, nil
 }
 
-func (c *Client) Balances(ctx context.Context, addr string, height *int64) ([]*rosettatypes.Amount, error) {
+func (c *Client) Balances(ctx context.Context, addr string, height *int64, currencies []*rosettatypes.Currency) ([]*rosettatypes.Amount, error) {
 	if height != nil {
 		strHeight := strconv.FormatInt(*height, 10)
 		ctx = metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strHeight)
 	}
 
-	balance, err := c.bank.AllBalances(ctx, &bank.QueryAllBalancesRequest{
-		Address: addr,
-	})
+	n, err := c.nodeAt(height)
+	if err != nil {
+		return nil, err
+	}
+	if len(currencies) != 0 {
+		return c.currencyBalances(ctx, n, addr, currencies)
+	}
+
+	balances, err := c.allBalances(ctx, n, addr)
 	if err != nil {
 		return nil, crgerrs.FromGRPCToRosettaError(err)
 	}
+	if c.config.HeldBalancesOnly {
+		return c.converter.ToRosetta().Amounts(balances, balances), nil
+	}
 
-	availableCoins, err := c.coins(ctx)
+	availableCoins, err := c.coins(ctx, n)
 	if err != nil {
 		return nil, crgerrs.FromGRPCToRosettaError(err)
 	}
 
-	return c.converter.ToRosetta().Amounts(balance.Balances, availableCoins), nil
+	return c.converter.ToRosetta().Amounts(balances, availableCoins), nil
+}
+
+// currencyBalances queries the balance of the address for each of the currencies only,
+// the currencies are returned even if the address does not hold them
+func (c *Client) currencyBalances(ctx context.Context, n *node, addr string, currencies []*rosettatypes.Currency) ([]*rosettatypes.Amount, error) {
+	balances := make(sdk.Coins, len(currencies))
+	for i, currency := range currencies {
+		balance, err := n.bank.Balance(ctx, &bank.QueryBalanceRequest{
+			Address: addr,
+			Denom:   currency.Symbol,
+		})
+		if err != nil {
+			return nil, crgerrs.FromGRPCToRosettaError(err)
+		}
+		balances[i] = sdk.NewCoin(currency.Symbol, sdkmath.ZeroInt())
+		if balance.Balance != nil {
+			balances[i] = *balance.Balance
+		}
+	}
+	return c.converter.ToRosetta().Amounts(balances, balances), nil
+}
+
+// allBalances fetches every page of the balances held by the address
+func (c *Client) allBalances(ctx context.Context, n *node, addr string) (sdk.Coins, error) {
+	var (
+		balances sdk.Coins
+		nextKey  []byte
+	)
+	for {
+		res, err := n.bank.AllBalances(ctx, &bank.QueryAllBalancesRequest{
+			Address:    addr,
+			Pagination: &query.PageRequest{Key: nextKey},
+		})
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, res.Balances...)
+		nextKey = res.GetPagination().GetNextKey()
+		if len(nextKey) == 0 {
+			return balances, nil
+		}
+	}
 }
 
 func (c *Client) BlockByHash(ctx context.Context, hash string) (crgtypes.BlockResponse, error) {
@@ -218,46 +425,116 @@ func (c *Client) BlockByHash(ctx context.Context, hash string) (crgtypes.BlockRe
 		return crgtypes.BlockResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("invalid block hash %s", err.Error()))
 	}
 
-	block, err := c.tmRPC.BlockByHash(ctx, bHash)
+	n, err := c.node()
+	if err != nil {
+		return crgtypes.BlockResponse{}, err
+	}
+	block, err := n.tmRPC.BlockByHash(ctx, bHash)
 	if err != nil {
 		return crgtypes.BlockResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting block by hash %s", err.Error()))
 	}
 
-	return c.converter.ToRosetta().BlockResponse(block), nil
+	return c.converter.ToRosetta().BlockResponse(block, nil, nil), nil
 }
 
 func (c *Client) BlockByHeight(ctx context.Context, height *int64) (crgtypes.BlockResponse, error) {
-	block, err := c.tmRPC.Block(ctx, height)
+	n, err := c.nodeAt(height)
+	if err != nil {
+		return crgtypes.BlockResponse{}, err
+	}
+	block, err := n.tmRPC.Block(ctx, height)
 	if err != nil {
 		return crgtypes.BlockResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting block by height %s", err.Error()))
 	}
 
-	return c.converter.ToRosetta().BlockResponse(block), nil
+	return c.converter.ToRosetta().BlockResponse(block, nil, nil), nil
 }
 
 func (c *Client) BlockTransactionsByHash(ctx context.Context, hash string) (crgtypes.BlockTransactionsResponse, error) {
+	if c.cache != nil {
+		if blockTxResp, ok := c.cache.block(blockHashKey(hash)); ok {
+			return blockTxResp, nil
+		}
+	}
+
 	// TODO(fdymylja): use a faster path, by searching the block by hash, instead of doing a double query operation
 	blockResp, err := c.BlockByHash(ctx, hash)
 	if err != nil {
 		return crgtypes.BlockTransactionsResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting block transactions by hash %s", err.Error()))
 	}
 
-	return c.blockTxs(ctx, &blockResp.Block.Index)
+	blockTxResp, err := c.blockTxs(ctx, &blockResp.Block.Index)
+	if err != nil {
+		return crgtypes.BlockTransactionsResponse{}, err
+	}
+	c.cacheBlock(ctx, blockTxResp)
+	return blockTxResp, nil
 }
 
 func (c *Client) BlockTransactionsByHeight(ctx context.Context, height *int64) (crgtypes.BlockTransactionsResponse, error) {
+	if c.cache != nil && height != nil {
+		if blockTxResp, ok := c.cache.block(blockHeightKey(*height)); ok {
+			return blockTxResp, nil
+		}
+	}
+
 	blockTxResp, err := c.blockTxs(ctx, height)
 	if err != nil {
 		return crgtypes.BlockTransactionsResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting block transactions by height %s", err.Error()))
 	}
+	if height == nil {
+		c.observeTip(blockTxResp.Block.Index)
+	}
+	c.cacheBlock(ctx, blockTxResp)
 	return blockTxResp, nil
 }
 
+// observeTip records the height of the tip of the chain, used to tell which blocks can be cached
+func (c *Client) observeTip(height int64) {
+	if c.cache != nil {
+		c.cache.observeHeight(height)
+	}
+}
+
+// belowTip reports whether the block at height is below the tip of the chain and can no longer
+// change, the tip is fetched from the node only if height is not below the last known tip
+func (c *Client) belowTip(ctx context.Context, height int64) bool {
+	if c.cache.immutable(height) {
+		return true
+	}
+	n, err := c.node()
+	if err != nil {
+		return false
+	}
+	status, err := n.tmRPC.Status(ctx)
+	if err != nil {
+		return false
+	}
+	c.cache.observeHeight(status.SyncInfo.LatestBlockHeight)
+	return c.cache.immutable(height)
+}
+
+// cacheBlock caches the block response if the block is below the tip of the chain
+func (c *Client) cacheBlock(ctx context.Context, blockTxResp crgtypes.BlockTransactionsResponse) {
+	if c.cache == nil || !c.belowTip(ctx, blockTxResp.Block.Index) {
+		return
+	}
+	c.cache.addBlock(blockTxResp, true)
+}
+
+// cacheTx caches the transaction if its block is below the tip of the chain
+func (c *Client) cacheTx(ctx context.Context, hash string, height int64, tx *rosettatypes.Transaction) {
+	if c.cache == nil || !c.belowTip(ctx, height) {
+		return
+	}
+	c.cache.addTx(hash, tx)
+}
+
 // Coins f etches the existing coins in the application
-func (c *Client) coins(ctx context.Context) (sdk.Coins, error) {
+func (c *Client) coins(ctx context.Context, n *node) (sdk.Coins, error) {
 	var result sdk.Coins
 
-	supply, err := c.bank.TotalSupply(ctx, &bank.QueryTotalSupplyRequest{})
+	supply, err := n.bank.TotalSupply(ctx, &bank.QueryTotalSupplyRequest{})
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting coins supply %s", err.Error()))
 	}
@@ -271,7 +548,7 @@ func (c *Client) coins(ctx context.Context) (sdk.Coins, error) {
 		}
 		nextKey := page.GetNextKey()
 
-		supply, err = c.bank.TotalSupply(ctx, &bank.QueryTotalSupplyRequest{Pagination: &query.PageRequest{Key: nextKey}})
+		supply, err = n.bank.TotalSupply(ctx, &bank.QueryTotalSupplyRequest{Pagination: &query.PageRequest{Key: nextKey}})
 		if err != nil {
 			return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting supply from bank %s", err.Error()))
 		}
@@ -282,7 +559,10 @@ func (c *Client) coins(ctx context.Context) (sdk.Coins, error) {
 	return result, nil
 }
 
-func (c *Client) TxOperationsAndSignersAccountIdentifiers(signed bool, txBytes []byte) (ops []*rosettatypes.Operation, signers []*rosettatypes.AccountIdentifier, err error) {
+func (c *Client) TxOperationsAndSignersAccountIdentifiers(ctx context.Context, signed bool, txBytes []byte) (ops []*rosettatypes.Operation, signers []*rosettatypes.AccountIdentifier, err error) {
+	_, span := startConverterSpan(ctx, "OpsAndSigners", attribute.Bool("signed", signed))
+	defer func() { endSpan(span, err) }()
+
 	switch signed {
 	case false:
 		rosTx, err := c.converter.ToRosetta().Tx(txBytes, nil)
@@ -305,6 +585,12 @@ func (c *Client) GetTx(ctx context.Context, hash string) (*rosettatypes.Transact
 		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("bad tx hash %s", err.Error()))
 	}
 
+	if c.cache != nil {
+		if tx, ok := c.cache.tx(hash); ok {
+			return tx, nil
+		}
+	}
+
 	// get tx type and hash
 	txType, hashBytes := c.converter.ToSDK().HashToTxType(hashBytes)
 
@@ -313,21 +599,26 @@ func (c *Client) GetTx(ctx context.Context, hash string) (*rosettatypes.Transact
 	// handle begin block hash
 	// handle deliver tx hash
 	case DeliverTxTx:
-		rawTx, err := c.tmRPC.Tx(ctx, hashBytes, true)
+		n, err := c.node()
+		if err != nil {
+			return nil, err
+		}
+		rawTx, err := n.tmRPC.Tx(ctx, hashBytes, true)
 		if err != nil {
 			return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting tx %s", err.Error()))
 		}
-		return c.converter.ToRosetta().Tx(rawTx.Tx, &rawTx.TxResult)
-	// handle end block hash
-	case FinalizeBlockTx:
-		// get block height by hash
-		block, err := c.tmRPC.BlockByHash(ctx, hashBytes)
+		_, span := startConverterSpan(ctx, "Tx")
+		rosTx, err := c.converter.ToRosetta().Tx(rawTx.Tx, &rawTx.TxResult)
+		endSpan(span, err)
 		if err != nil {
-			return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting block by hash %s", err.Error()))
+			return nil, err
 		}
-
-		// get block txs
-		fullBlock, err := c.blockTxs(ctx, &block.Block.Height)
+		c.cacheTx(ctx, hash, rawTx.Height, rosTx)
+		return rosTx, nil
+	// handle end block hash
+	case FinalizeBlockTx:
+		// get block txs, the block is cached by hash
+		fullBlock, err := c.BlockTransactionsByHash(ctx, fmt.Sprintf("%X", hashBytes))
 		if err != nil {
 			return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting block by hash %s", err.Error()))
 		}
@@ -342,7 +633,11 @@ func (c *Client) GetTx(ctx context.Context, hash string) (*rosettatypes.Transact
 
 // GetUnconfirmedTx gets an unconfirmed transaction given its hash
 func (c *Client) GetUnconfirmedTx(ctx context.Context, hash string) (*rosettatypes.Transaction, error) {
-	res, err := c.tmRPC.UnconfirmedTxs(ctx, nil)
+	n, err := c.node()
+	if err != nil {
+		return nil, err
+	}
+	res, err := n.tmRPC.UnconfirmedTxs(ctx, nil)
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrNotFound, fmt.Sprintf("unconfirmed tx not found %s", err.Error()))
 	}
@@ -375,7 +670,11 @@ func (c *Client) GetUnconfirmedTx(ctx context.Context, hash string) (*rosettatyp
 
 // Mempool returns the unconfirmed transactions in the mempool
 func (c *Client) Mempool(ctx context.Context) ([]*rosettatypes.TransactionIdentifier, error) {
-	txs, err := c.tmRPC.UnconfirmedTxs(ctx, nil)
+	n, err := c.node()
+	if err != nil {
+		return nil, err
+	}
+	txs, err := n.tmRPC.UnconfirmedTxs(ctx, nil)
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting unconfirmed tx %s", err.Error()))
 	}
@@ -385,7 +684,11 @@ func (c *Client) Mempool(ctx context.Context) ([]*rosettatypes.TransactionIdenti
 
 // Peers gets the number of peers
 func (c *Client) Peers(ctx context.Context) ([]*rosettatypes.Peer, error) {
-	netInfo, err := c.tmRPC.NetInfo(ctx)
+	n, err := c.node()
+	if err != nil {
+		return nil, err
+	}
+	netInfo, err := n.tmRPC.NetInfo(ctx)
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, "getting network information "+err.Error())
 	}
@@ -393,16 +696,25 @@ func (c *Client) Peers(ctx context.Context) ([]*rosettatypes.Peer, error) {
 }
 
 func (c *Client) Status(ctx context.Context) (*rosettatypes.SyncStatus, error) {
-	status, err := c.tmRPC.Status(ctx)
+	n, err := c.node()
+	if err != nil {
+		return nil, err
+	}
+	status, err := n.tmRPC.Status(ctx)
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting network information %s", err.Error()))
 	}
+	c.observeTip(status.SyncInfo.LatestBlockHeight)
 	return c.converter.ToRosetta().SyncStatus(status), err
 }
 
-func (c *Client) PostTx(txBytes []byte) (*rosettatypes.TransactionIdentifier, map[string]interface{}, error) {
+func (c *Client) PostTx(ctx context.Context, txBytes []byte) (*rosettatypes.TransactionIdentifier, map[string]interface{}, error) {
 	// sync ensures it will go through checkTx
-	res, err := c.tmRPC.BroadcastTxSync(context.Background(), txBytes)
+	n, err := c.node()
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := n.tmRPC.BroadcastTxSync(ctx, txBytes)
 	if err != nil {
 		return nil, nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting BroadcastTxSync %s", err.Error()))
 	}
@@ -469,7 +781,11 @@ func (c *Client) ConstructionMetadataFromOptions(ctx context.Context, options ma
 		signersData[i] = accountInfo
 	}
 
-	status, err := c.tmRPC.Status(ctx)
+	n, err := c.node()
+	if err != nil {
+		return nil, err
+	}
+	status, err := n.tmRPC.Status(ctx)
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting rpc status %s", err.Error()))
 	}
@@ -486,45 +802,61 @@ func (c *Client) ConstructionMetadataFromOptions(ctx context.Context, options ma
 }
 
 func (c *Client) blockTxs(ctx context.Context, height *int64) (crgtypes.BlockTransactionsResponse, error) {
+	// the block and its results must come from the same node
+	n, err := c.nodeAt(height)
+	if err != nil {
+		return crgtypes.BlockTransactionsResponse{}, err
+	}
 	// get block info
-	blockInfo, err := c.tmRPC.Block(ctx, height)
+	blockInfo, err := n.tmRPC.Block(ctx, height)
 	if err != nil {
 		return crgtypes.BlockTransactionsResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting rpc block %s", err.Error()))
 	}
 	// get block events
-	blockResults, err := c.tmRPC.BlockResults(ctx, height)
+	blockResults, err := n.tmRPC.BlockResults(ctx, &blockInfo.Block.Height)
 	if err != nil {
 		return crgtypes.BlockTransactionsResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting rpc block results %s", err.Error()))
 	}
 
+	params, err := n.tmRPC.ConsensusParams(ctx, &blockInfo.Block.Height)
+	if err != nil {
+		return crgtypes.BlockTransactionsResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting rpc consensus params %s", err.Error()))
+	}
+
 	if len(blockResults.TxResults) != len(blockInfo.Block.Txs) {
 		return crgtypes.BlockTransactionsResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, "block results transactions do now match block transactions")
 	}
 	// process begin and end block txs
+	_, span := startConverterSpan(ctx, "BalanceOps", attribute.Int("events", len(blockResults.FinalizeBlockEvents)))
+	finalizeBlockOps, err := c.converter.ToRosetta().BalanceOps(StatusTxSuccess, blockResults.FinalizeBlockEvents)
+	endSpan(span, err)
+	if err != nil {
+		return crgtypes.BlockTransactionsResponse{}, err
+	}
 	finalizeBlockTx := &rosettatypes.Transaction{
 		TransactionIdentifier: &rosettatypes.TransactionIdentifier{Hash: c.converter.ToRosetta().FinalizeBlockTxHash(blockInfo.BlockID.Hash)},
-		Operations: AddOperationIndexes(
-			nil,
-			c.converter.ToRosetta().BalanceOps(StatusTxSuccess, blockResults.FinalizeBlockEvents),
-		),
+		Operations:            AddOperationIndexes(nil, finalizeBlockOps),
 	}
 
 	deliverTx := make([]*rosettatypes.Transaction, len(blockInfo.Block.Txs))
 	// process normal txs
+	_, span = startConverterSpan(ctx, "Txs", attribute.Int("txs", len(blockInfo.Block.Txs)))
 	for i, tx := range blockInfo.Block.Txs {
 		rosTx, err := c.converter.ToRosetta().Tx(tx, blockResults.TxResults[i])
 		if err != nil {
+			endSpan(span, err)
 			return crgtypes.BlockTransactionsResponse{}, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting rosetta tx %s", err.Error()))
 		}
 		deliverTx[i] = rosTx
 	}
+	endSpan(span, nil)
 
 	finalTxs := make([]*rosettatypes.Transaction, 0, 1+len(deliverTx))
 	finalTxs = append(finalTxs, deliverTx...)
 	finalTxs = append(finalTxs, finalizeBlockTx)
 
 	return crgtypes.BlockTransactionsResponse{
-		BlockResponse: c.converter.ToRosetta().BlockResponse(blockInfo),
+		BlockResponse: c.converter.ToRosetta().BlockResponse(blockInfo, blockResults, params),
 		Transactions:  finalTxs,
 	}, nil
 }
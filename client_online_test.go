@@ -1,10 +1,24 @@
 package rosetta
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"testing"
 
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	tmrpc "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	bank "cosmossdk.io/x/bank/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
 )
 
 func TestRegex(t *testing.T) {
@@ -13,3 +27,153 @@ func TestRegex(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, height, int64(5900001))
 }
+
+// newTestClient returns a client whose node is served by the given connections
+func newTestClient(t *testing.T, conf *Config, conn *node) *Client {
+	t.Helper()
+	conf.Blockchain, conf.Network = "cosmos", "cosmoshub-4"
+	conf.TendermintRPC, conf.GRPCEndpoint = "localhost:26657", "localhost:9090"
+	conf.GasToSuggest, conf.Bech32Prefix = 200000, "cosmos"
+	cdc, ir := MakeCodec()
+	conf.WithCodec(ir, cdc)
+	require.NoError(t, conf.validate())
+
+	client, err := NewClient(conf)
+	require.NoError(t, err)
+	client.pool.nodes[0].conn.Store(conn)
+	return client
+}
+
+// bankClient serves the balances of a single account, two denoms per page
+type bankClient struct {
+	bank.QueryClient
+	balances sdk.Coins
+}
+
+func (c bankClient) Balance(_ context.Context, req *bank.QueryBalanceRequest, _ ...grpc.CallOption) (*bank.QueryBalanceResponse, error) {
+	balance := sdk.NewCoin(req.Denom, c.balances.AmountOf(req.Denom))
+	return &bank.QueryBalanceResponse{Balance: &balance}, nil
+}
+
+func (c bankClient) AllBalances(_ context.Context, req *bank.QueryAllBalancesRequest, _ ...grpc.CallOption) (*bank.QueryAllBalancesResponse, error) {
+	start := 0
+	if req.Pagination != nil && len(req.Pagination.Key) != 0 {
+		start = int(req.Pagination.Key[0])
+	}
+	end := min(start+2, len(c.balances))
+	res := &bank.QueryAllBalancesResponse{Balances: c.balances[start:end], Pagination: &query.PageResponse{}}
+	if end < len(c.balances) {
+		res.Pagination.NextKey = []byte{byte(end)}
+	}
+	return res, nil
+}
+
+func TestClient_Balances(t *testing.T) {
+	balances := sdk.NewCoins(sdk.NewInt64Coin("atom", 1), sdk.NewInt64Coin("ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", 2), sdk.NewInt64Coin("stake", 3))
+	client := newTestClient(t, &Config{HeldBalancesOnly: true}, &node{bank: bankClient{balances: balances}})
+	ctx := context.Background()
+	amount := func(value, denom string) *rosettatypes.Amount {
+		return &rosettatypes.Amount{Value: value, Currency: &rosettatypes.Currency{Symbol: denom}}
+	}
+
+	// every page of the held denoms is returned
+	amounts, err := client.Balances(ctx, "cosmos1address", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*rosettatypes.Amount{
+		amount("1", "atom"),
+		amount("2", "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"),
+		amount("3", "stake"),
+	}, amounts)
+
+	// only the requested currencies are returned, in the requested order
+	amounts, err = client.Balances(ctx, "cosmos1address", nil, []*rosettatypes.Currency{{Symbol: "stake"}, {Symbol: "uosmo"}})
+	require.NoError(t, err)
+	require.Equal(t, []*rosettatypes.Amount{amount("3", "stake"), amount("0", "uosmo")}, amounts)
+}
+
+func TestClient_Close(t *testing.T) {
+	conn, err := dialNode(nodeEndpoints{grpc: "localhost:9090", tendermintRPC: "tcp://localhost:26657"}, nodeTransport{})
+	require.NoError(t, err)
+	client := newTestClient(t, &Config{}, conn)
+	require.NoError(t, client.Close())
+
+	// a closed client fails instead of using the closed connections
+	_, err = client.Status(context.Background())
+	require.ErrorIs(t, err, crgerrs.ErrOnlineClient)
+	_, err = client.Balances(context.Background(), "cosmos1address", nil, nil)
+	require.ErrorIs(t, err, crgerrs.ErrOnlineClient)
+}
+
+// earliestClient serves the blocks from its earliest height
+type earliestClient struct {
+	tmrpc.Client
+	earliest int64
+}
+
+func (c earliestClient) Status(context.Context) (*coretypes.ResultStatus, error) {
+	return &coretypes.ResultStatus{SyncInfo: coretypes.SyncInfo{EarliestBlockHeight: c.earliest, LatestBlockHeight: 1000}}, nil
+}
+
+func (c earliestClient) Block(_ context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	if *height < c.earliest {
+		return nil, errors.New("height is not available")
+	}
+	return &coretypes.ResultBlock{Block: &cmttypes.Block{Header: cmttypes.Header{Height: *height}}}, nil
+}
+
+// resultsClient serves the blocks with their results and consensus params, and counts the results queries
+type resultsClient struct {
+	earliestClient
+	results int
+}
+
+func (c *resultsClient) BlockResults(context.Context, *int64) (*coretypes.ResultBlockResults, error) {
+	c.results++
+	return &coretypes.ResultBlockResults{}, nil
+}
+
+func (*resultsClient) ConsensusParams(context.Context, *int64) (*coretypes.ResultConsensusParams, error) {
+	return &coretypes.ResultConsensusParams{ConsensusParams: cmttypes.ConsensusParams{Version: cmttypes.VersionParams{App: 3}}}, nil
+}
+
+func TestClient_BlockResults(t *testing.T) {
+	rpc := &resultsClient{earliestClient: earliestClient{earliest: 1}}
+	client := newTestClient(t, &Config{}, &node{tmRPC: rpc})
+	height := int64(10)
+
+	// the block identifier is served by a single query
+	block, err := client.BlockByHeight(context.Background(), &height)
+	require.NoError(t, err)
+	require.Equal(t, height, block.Block.Index)
+	require.NotContains(t, block.Metadata, "gas_used")
+	require.Zero(t, rpc.results)
+
+	// the block served by /block holds the metadata of its results and consensus params
+	blockTxs, err := client.BlockTransactionsByHeight(context.Background(), &height)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), blockTxs.Metadata["gas_used"])
+	require.Equal(t, uint64(3), blockTxs.Metadata["consensus_app_version"])
+	require.Equal(t, 1, rpc.results)
+}
+
+func TestClient_OldestBlock(t *testing.T) {
+	client := newTestClient(t, &Config{ArchiveTendermintRPC: "localhost:36657", ArchiveGRPCEndpoint: "localhost:19090"}, &node{tmRPC: earliestClient{earliest: 850}})
+	ctx := context.Background()
+
+	// the archive node is not connected
+	block, err := client.OldestBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(850), block.Block.Index)
+
+	// the archive node keeps the oldest blocks
+	client.pool.archive.conn.Store(&node{tmRPC: earliestClient{earliest: 1}})
+	block, err = client.OldestBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), block.Block.Index)
+
+	// the archive node stops responding
+	client.pool.archive.status.Store(&nodeStatus{})
+	block, err = client.OldestBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(850), block.Block.Index)
+}
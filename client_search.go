@@ -0,0 +1,356 @@
+package rosetta
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+const (
+	// maxSearchLimit is the maximum number of transactions returned by a search,
+	// which is also the maximum page size of CometBFT tx_search
+	maxSearchLimit = 100
+	// searchCursorIndexBits is the number of bits of the search cursor holding the
+	// index of the transaction in its block, the block height uses the other bits
+	searchCursorIndexBits = 24
+	// hashPrefixSearchBlocks is the number of blocks, below max_block or the tip of the chain,
+	// scanned for the transactions matching a hash prefix, which tx_search cannot match
+	hashPrefixSearchBlocks = 1000
+	// txHashLength is the length of a hex encoded transaction hash
+	txHashLength = 64
+	// maxSearchPages is the number of tx_search pages of a query scanned by a search whose filter
+	// matches few transactions, the search then returns a cursor to resume the scan below
+	maxSearchPages = 10
+)
+
+// addressEvents are the event attributes matching the transactions which touched an address
+var addressEvents = []string{
+	"message.sender",
+	"transfer.recipient",
+	"coin_spent.spender",
+	"coin_received.receiver",
+}
+
+// SearchTransactions searches the transactions indexed by the node matching the request, from
+// the most recent to the oldest. The supported conditions are the transaction hash and the
+// address, combined with the request operator, while max_block, success and status filter the
+// results. A hash shorter than a full hash is a prefix matched against the transactions of the
+// last hashPrefixSearchBlocks blocks below max_block or the tip of the chain. A search matching
+// only the success or the status requires max_block, and every query scans at most maxSearchPages
+// pages of tx_search per call, all of them on the same node.
+//
+// The offset of the request is a cursor: the transactions older than the cursor are returned and
+// the response next offset is the cursor of the next page, which is not affected by new blocks.
+// A page is returned with less transactions than the limit, or none, when the scan stops before the
+// oldest transaction, its next offset resumes the scan.
+// The total count sums the results of the CometBFT queries of the search below the cursor, as they
+// cannot express OR it is exact only for a single query without filter, else an upper bound.
+func (c *Client) SearchTransactions(ctx context.Context, request *rosettatypes.SearchTransactionsRequest) (*rosettatypes.SearchTransactionsResponse, error) {
+	filter, err := newSearchFilter(request)
+	if err != nil {
+		return nil, err
+	}
+	queries, err := c.searchQueries(request, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := maxSearchLimit
+	if request.Limit != nil && *request.Limit > 0 && *request.Limit < maxSearchLimit {
+		limit = int(*request.Limit)
+	}
+	var cursor searchCursor
+	if request.Offset != nil {
+		cursor = searchCursor(*request.Offset)
+	}
+	maxHeight := int64(-1)
+	if request.MaxBlock != nil {
+		maxHeight = *request.MaxBlock
+	}
+	if !cursor.isStart() && (maxHeight < 0 || cursor.height() < maxHeight) {
+		maxHeight = cursor.height()
+	}
+	// the success and the status alone would scan the whole chain
+	if maxHeight < 0 && filter.success != nil && filter.hashPrefix == "" &&
+		request.TransactionIdentifier == nil && request.Address == nil && request.AccountIdentifier == nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, "the success and status conditions require max_block or another condition")
+	}
+
+	// the pages of a search are queried from the same node
+	n, err := c.node()
+	if err != nil {
+		return nil, err
+	}
+	// the hash prefix is matched in a bounded range of blocks
+	minHeight := int64(0)
+	if filter.hashPrefix != "" {
+		minHeight, err = c.hashPrefixMinHeight(ctx, n, maxHeight)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		results    []*coretypes.ResultTx
+		totalCount int64
+		seen       = make(map[string]struct{})
+		// stop is the highest cursor a query stopped its scan at, the transactions
+		// below it are returned by the next page
+		stop searchCursor
+	)
+	for _, query := range queries {
+		if minHeight > 0 {
+			query = fmt.Sprintf("%s AND tx.height > %d", query, minHeight)
+		}
+		if maxHeight >= 0 {
+			query = fmt.Sprintf("%s AND tx.height <= %d", query, maxHeight)
+		}
+		// one more transaction tells whether there is a next page
+		txs, count, queryStop, err := c.searchTxs(ctx, n, query, cursor, filter, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		totalCount += int64(count)
+		stop = max(stop, queryStop)
+		for _, tx := range txs {
+			if _, ok := seen[tx.Hash.String()]; ok {
+				continue
+			}
+			seen[tx.Hash.String()] = struct{}{}
+			results = append(results, tx)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return newSearchCursor(results[j]) < newSearchCursor(results[i])
+	})
+	if !stop.isStart() {
+		// the other queries may have scanned below the stop cursor
+		results = slices.DeleteFunc(results, func(tx *coretypes.ResultTx) bool { return newSearchCursor(tx) < stop })
+	}
+
+	response := &rosettatypes.SearchTransactionsResponse{
+		Transactions: []*rosettatypes.BlockTransaction{},
+		TotalCount:   totalCount,
+	}
+	switch {
+	case len(results) > limit:
+		results = results[:limit]
+		next := int64(newSearchCursor(results[limit-1]))
+		response.NextOffset = &next
+	case !stop.isStart():
+		next := int64(stop)
+		response.NextOffset = &next
+	}
+
+	blocks := make(map[int64]*rosettatypes.BlockIdentifier)
+	for _, result := range results {
+		block, ok := blocks[result.Height]
+		if !ok {
+			height := result.Height
+			blockResp, err := c.BlockByHeight(ctx, &height)
+			if err != nil {
+				return nil, err
+			}
+			block = blockResp.Block
+			blocks[result.Height] = block
+		}
+
+		_, span := startConverterSpan(ctx, "Tx")
+		tx, err := c.converter.ToRosetta().Tx(result.Tx, &result.TxResult)
+		endSpan(span, err)
+		if err != nil {
+			return nil, err
+		}
+		response.Transactions = append(response.Transactions, &rosettatypes.BlockTransaction{
+			BlockIdentifier: block,
+			Transaction:     tx,
+		})
+	}
+	return response, nil
+}
+
+// searchFilter filters the transactions returned by the CometBFT queries of a search
+type searchFilter struct {
+	// success matches the successful or the failed transactions, if not nil
+	success *bool
+	// hashPrefix matches the transactions whose uppercase hex hash starts with it, if not empty
+	hashPrefix string
+}
+
+// newSearchFilter returns the filter of the search conditions which are not expressed as CometBFT queries
+func newSearchFilter(request *rosettatypes.SearchTransactionsRequest) (searchFilter, error) {
+	filter := searchFilter{success: request.Success}
+	if request.Status != nil {
+		var success bool
+		switch *request.Status {
+		case StatusTxSuccess:
+			success = true
+		case StatusTxReverted:
+			success = false
+		default:
+			return searchFilter{}, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("unsupported status %s, expected %s or %s", *request.Status, StatusTxSuccess, StatusTxReverted))
+		}
+		if filter.success != nil && *filter.success != success {
+			return searchFilter{}, crgerrs.WrapError(crgerrs.ErrBadArgument, "the status and success conditions do not match")
+		}
+		filter.success = &success
+	}
+
+	if request.TransactionIdentifier != nil && len(request.TransactionIdentifier.Hash) < txHashLength {
+		prefix := strings.ToUpper(request.TransactionIdentifier.Hash)
+		// an odd number of hex characters is padded to be decoded
+		if _, err := hex.DecodeString(prefix + strings.Repeat("0", len(prefix)%2)); err != nil || prefix == "" {
+			return searchFilter{}, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("invalid tx hash prefix %q", request.TransactionIdentifier.Hash))
+		}
+		filter.hashPrefix = prefix
+	}
+	return filter, nil
+}
+
+// match reports whether the transaction passes the filter
+func (f searchFilter) match(tx *coretypes.ResultTx) bool {
+	if f.success != nil && tx.TxResult.IsOK() != *f.success {
+		return false
+	}
+	return strings.HasPrefix(tx.Hash.String(), f.hashPrefix)
+}
+
+// hashPrefixMinHeight returns the height above which the transactions are matched against
+// a hash prefix, maxHeight is the height of the search, the tip of the chain of n if negative
+func (c *Client) hashPrefixMinHeight(ctx context.Context, n *node, maxHeight int64) (int64, error) {
+	if maxHeight < 0 {
+		status, err := n.tmRPC.Status(ctx)
+		if err != nil {
+			return 0, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting network information %s", err.Error()))
+		}
+		c.observeTip(status.SyncInfo.LatestBlockHeight)
+		maxHeight = status.SyncInfo.LatestBlockHeight
+	}
+	return max(maxHeight-hashPrefixSearchBlocks, 0), nil
+}
+
+// searchQueries returns the CometBFT queries whose results together match the conditions of the request,
+// except the conditions of the filter which are matched against the results
+func (c *Client) searchQueries(request *rosettatypes.SearchTransactionsRequest, filter searchFilter) ([]string, error) {
+	switch {
+	case request.CoinIdentifier != nil, request.Currency != nil, request.Type != nil:
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, "only the transaction_identifier, address, account_identifier, max_block, status and success search conditions are supported")
+	}
+
+	// every condition is a set of alternative queries
+	var conditions [][]string
+	if request.TransactionIdentifier != nil && filter.hashPrefix == "" {
+		hash, err := hex.DecodeString(request.TransactionIdentifier.Hash)
+		if err != nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("invalid tx hash %s", err.Error()))
+		}
+		conditions = append(conditions, []string{fmt.Sprintf("tx.hash = '%X'", hash)})
+	}
+	var addresses []string
+	if request.Address != nil {
+		addresses = append(addresses, *request.Address)
+	}
+	if request.AccountIdentifier != nil {
+		addresses = append(addresses, request.AccountIdentifier.Address)
+	}
+	for _, addr := range addresses {
+		// the address is validated as it is part of the query
+		if _, err := c.addressCodec.StringToBytes(addr); err != nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrInvalidAddress, fmt.Sprintf("invalid address %s: %s", addr, err.Error()))
+		}
+		queries := make([]string, len(addressEvents))
+		for i, event := range addressEvents {
+			queries[i] = fmt.Sprintf("%s = '%s'", event, addr)
+		}
+		conditions = append(conditions, queries)
+	}
+
+	if len(conditions) == 0 {
+		return []string{"tx.height > 0"}, nil
+	}
+	if request.Operator != nil && *request.Operator == rosettatypes.OR {
+		if filter.success != nil || filter.hashPrefix != "" {
+			return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, "the success, status and hash prefix conditions are not supported with the or operator")
+		}
+		var queries []string
+		for _, condition := range conditions {
+			queries = append(queries, condition...)
+		}
+		return queries, nil
+	}
+
+	queries := []string{""}
+	for _, condition := range conditions {
+		combined := make([]string, 0, len(queries)*len(condition))
+		for _, query := range queries {
+			for _, alternative := range condition {
+				if query == "" {
+					combined = append(combined, alternative)
+				} else {
+					combined = append(combined, query+" AND "+alternative)
+				}
+			}
+		}
+		queries = combined
+	}
+	return queries, nil
+}
+
+// searchTxs returns at least limit transactions, if available, matching the query which are older than the
+// cursor and match the filter, from the most recent one, and the total count of the query. The scan stops
+// at a block boundary after maxSearchPages pages, the stop cursor is then set above the blocks not scanned.
+func (c *Client) searchTxs(ctx context.Context, n *node, query string, cursor searchCursor, filter searchFilter, limit int) (txs []*coretypes.ResultTx, count int, stop searchCursor, err error) {
+	perPage := maxSearchLimit
+	lastHeight := int64(-1)
+	for page := 1; ; page++ {
+		res, err := n.tmRPC.TxSearch(ctx, query, false, &page, &perPage, "desc")
+		if err != nil {
+			return nil, 0, 0, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("searching txs %s", err.Error()))
+		}
+		for _, tx := range res.Txs {
+			// the transactions of a block are not sorted by index, so the block of the
+			// last transaction is always returned entirely
+			if len(txs) >= limit && tx.Height != txs[len(txs)-1].Height {
+				return txs, res.TotalCount, 0, nil
+			}
+			if page > maxSearchPages && tx.Height != lastHeight {
+				return txs, res.TotalCount, searchCursor((tx.Height + 1) << searchCursorIndexBits), nil
+			}
+			lastHeight = tx.Height
+			if !cursor.isStart() && newSearchCursor(tx) >= cursor {
+				continue
+			}
+			if !filter.match(tx) {
+				continue
+			}
+			txs = append(txs, tx)
+		}
+		if len(res.Txs) < perPage || page*perPage >= res.TotalCount {
+			return txs, res.TotalCount, 0, nil
+		}
+	}
+}
+
+// searchCursor is the position of a transaction in the chain, ordered by block height
+// and then by index in the block, the zero cursor is the start of a search
+type searchCursor int64
+
+func newSearchCursor(tx *coretypes.ResultTx) searchCursor {
+	return searchCursor(tx.Height<<searchCursorIndexBits | int64(tx.Index))
+}
+
+func (c searchCursor) isStart() bool {
+	return c <= 0
+}
+
+func (c searchCursor) height() int64 {
+	return int64(c) >> searchCursorIndexBits
+}
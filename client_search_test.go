@@ -0,0 +1,187 @@
+package rosetta
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	tmrpc "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// searchClient indexes transactions by query, the results of a height
+// are not sorted by index like the CometBFT kv indexer
+type searchClient struct {
+	tmrpc.Client
+	txs map[string][]*coretypes.ResultTx
+}
+
+func (c *searchClient) TxSearch(_ context.Context, query string, _ bool, page, perPage *int, _ string) (*coretypes.ResultTxSearch, error) {
+	condition, bound, _ := strings.Cut(query, " AND tx.height <= ")
+	maxHeight := int64(-1)
+	if bound != "" {
+		maxHeight, _ = strconv.ParseInt(bound, 10, 64)
+	}
+	condition, lowerBound, _ := strings.Cut(condition, " AND tx.height > ")
+	minHeight := int64(0)
+	if lowerBound != "" {
+		minHeight, _ = strconv.ParseInt(lowerBound, 10, 64)
+	}
+
+	var txs []*coretypes.ResultTx
+	for _, tx := range c.txs[condition] {
+		if (maxHeight < 0 || tx.Height <= maxHeight) && tx.Height > minHeight {
+			txs = append(txs, tx)
+		}
+	}
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].Height > txs[j].Height })
+	start := min((*page-1)**perPage, len(txs))
+	end := min(start+*perPage, len(txs))
+	return &coretypes.ResultTxSearch{Txs: txs[start:end], TotalCount: len(txs)}, nil
+}
+
+func (c *searchClient) Block(_ context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	return &coretypes.ResultBlock{Block: &cmttypes.Block{Header: cmttypes.Header{Height: *height}}}, nil
+}
+
+func (c *searchClient) Status(context.Context) (*coretypes.ResultStatus, error) {
+	return &coretypes.ResultStatus{SyncInfo: coretypes.SyncInfo{LatestBlockHeight: 10}}, nil
+}
+
+func TestClient_SearchTransactions(t *testing.T) {
+	rpc := &searchClient{txs: make(map[string][]*coretypes.ResultTx)}
+	client := newTestClient(t, &Config{}, &node{tmRPC: rpc})
+	conv := client.converter.(converter)
+
+	newTx := func(height int64, index uint32, code uint32) *coretypes.ResultTx {
+		// the memo makes the transaction hashes unique
+		builder := conv.newTxBuilder()
+		builder.SetMemo(strconv.FormatInt(height, 10) + "/" + strconv.Itoa(int(index)))
+		txBytes, err := conv.txEncode(builder.GetTx())
+		require.NoError(t, err)
+		return &coretypes.ResultTx{
+			Hash:     cmttypes.Tx(txBytes).Hash(),
+			Height:   height,
+			Index:    index,
+			Tx:       txBytes,
+			TxResult: abcitypes.ExecTxResult{Code: code},
+		}
+	}
+	addr := sdk.AccAddress("address").String()
+	sent := []*coretypes.ResultTx{newTx(10, 0, 0), newTx(8, 1, 0), newTx(8, 3, 1), newTx(5, 0, 0)}
+	received := []*coretypes.ResultTx{newTx(9, 0, 0), newTx(8, 2, 0), sent[0]}
+	rpc.txs["message.sender = '"+addr+"'"] = sent
+	rpc.txs["coin_received.receiver = '"+addr+"'"] = received
+
+	type position struct {
+		height int64
+		index  uint32
+	}
+	search := func(request *rosettatypes.SearchTransactionsRequest) ([]position, *int64) {
+		res, err := client.SearchTransactions(context.Background(), request)
+		require.NoError(t, err)
+		positions := make([]position, len(res.Transactions))
+		for i, tx := range res.Transactions {
+			positions[i].height = tx.BlockIdentifier.Index
+			for _, result := range append(sent, received...) {
+				if strings.EqualFold(result.Hash.String(), tx.Transaction.TransactionIdentifier.Hash) {
+					positions[i].index = result.Index
+				}
+			}
+		}
+		return positions, res.NextOffset
+	}
+	limit := func(l int64) *int64 { return &l }
+
+	// the transactions touching the address are merged from the most recent one
+	txs, next := search(&rosettatypes.SearchTransactionsRequest{Address: &addr})
+	require.Equal(t, []position{{10, 0}, {9, 0}, {8, 3}, {8, 2}, {8, 1}, {5, 0}}, txs)
+	require.Nil(t, next)
+
+	// pages end in the middle of a block
+	txs, next = search(&rosettatypes.SearchTransactionsRequest{Address: &addr, Limit: limit(3)})
+	require.Equal(t, []position{{10, 0}, {9, 0}, {8, 3}}, txs)
+	require.NotNil(t, next)
+	txs, next = search(&rosettatypes.SearchTransactionsRequest{Address: &addr, Limit: limit(3), Offset: next})
+	require.Equal(t, []position{{8, 2}, {8, 1}, {5, 0}}, txs)
+	require.Nil(t, next)
+
+	// max block and success filters
+	success := true
+	txs, _ = search(&rosettatypes.SearchTransactionsRequest{Address: &addr, MaxBlock: limit(8), Success: &success})
+	require.Equal(t, []position{{8, 2}, {8, 1}, {5, 0}}, txs)
+
+	// address and hash
+	hash := sent[1].Hash.String()
+	rpc.txs["tx.hash = '"+hash+"' AND message.sender = '"+addr+"'"] = []*coretypes.ResultTx{sent[1]}
+	txs, _ = search(&rosettatypes.SearchTransactionsRequest{
+		Address:               &addr,
+		TransactionIdentifier: &rosettatypes.TransactionIdentifier{Hash: strings.ToLower(hash)},
+	})
+	require.Equal(t, []position{{8, 1}}, txs)
+
+	// the status filters the results like success
+	reverted := StatusTxReverted
+	txs, _ = search(&rosettatypes.SearchTransactionsRequest{Address: &addr, Status: &reverted})
+	require.Equal(t, []position{{8, 3}}, txs)
+	_, err := client.SearchTransactions(context.Background(), &rosettatypes.SearchTransactionsRequest{Address: &addr, Status: &reverted, Success: &success})
+	require.Error(t, err)
+	unknown := "unknown"
+	_, err = client.SearchTransactions(context.Background(), &rosettatypes.SearchTransactionsRequest{Address: &addr, Status: &unknown})
+	require.Error(t, err)
+
+	// hash prefixes are matched against the transactions of the last blocks
+	rpc.txs["tx.height > 0"] = append(append([]*coretypes.ResultTx{}, sent...), received[:2]...)
+	prefix := &rosettatypes.TransactionIdentifier{Hash: strings.ToLower(hash[:7])}
+	txs, _ = search(&rosettatypes.SearchTransactionsRequest{TransactionIdentifier: prefix})
+	require.Equal(t, []position{{8, 1}}, txs)
+	txs, _ = search(&rosettatypes.SearchTransactionsRequest{TransactionIdentifier: prefix, MaxBlock: limit(8 + hashPrefixSearchBlocks)})
+	require.Empty(t, txs)
+	txs, _ = search(&rosettatypes.SearchTransactionsRequest{TransactionIdentifier: prefix, Address: &addr})
+	require.Equal(t, []position{{8, 1}}, txs)
+
+	// the success and the status alone require a height range
+	_, err = client.SearchTransactions(context.Background(), &rosettatypes.SearchTransactionsRequest{Success: &success})
+	require.ErrorIs(t, err, crgerrs.ErrBadArgument)
+	txs, _ = search(&rosettatypes.SearchTransactionsRequest{Status: &reverted, MaxBlock: limit(10)})
+	require.Equal(t, []position{{8, 3}}, txs)
+
+	// a filter matching few transactions scans a bounded number of pages per call
+	other := sdk.AccAddress("other").String()
+	var failed []*coretypes.ResultTx
+	for height := int64(2000); height > 900; height-- {
+		code := uint32(1)
+		if height == 1500 || height == 901 {
+			code = 0
+		}
+		failed = append(failed, newTx(height, 0, code))
+	}
+	rpc.txs["message.sender = '"+other+"'"] = failed
+	txs, next = search(&rosettatypes.SearchTransactionsRequest{Address: &other, Success: &success})
+	require.Equal(t, []position{{1500, 0}}, txs)
+	require.NotNil(t, next)
+	txs, next = search(&rosettatypes.SearchTransactionsRequest{Address: &other, Success: &success, Offset: next})
+	require.Equal(t, []position{{901, 0}}, txs)
+	require.Nil(t, next)
+
+	// values are validated before being part of a query
+	invalid := "cosmos1' OR tx.height > '0"
+	_, err = client.SearchTransactions(context.Background(), &rosettatypes.SearchTransactionsRequest{Address: &invalid})
+	require.Error(t, err)
+	_, err = client.SearchTransactions(context.Background(), &rosettatypes.SearchTransactionsRequest{
+		TransactionIdentifier: &rosettatypes.TransactionIdentifier{Hash: "' OR"},
+	})
+	require.Error(t, err)
+	_, err = client.SearchTransactions(context.Background(), &rosettatypes.SearchTransactionsRequest{Currency: &rosettatypes.Currency{Symbol: "stake"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,288 @@
+package rosetta
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	queryv1beta1 "cosmossdk.io/api/cosmos/base/query/v1beta1"
+	basev1beta1 "cosmossdk.io/api/cosmos/base/v1beta1"
+	distributionv1beta1 "cosmossdk.io/api/cosmos/distribution/v1beta1"
+	stakingv1beta1 "cosmossdk.io/api/cosmos/staking/v1beta1"
+	sdkmath "cosmossdk.io/math"
+	bank "cosmossdk.io/x/bank/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	auth "github.com/cosmos/cosmos-sdk/x/auth/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// sub accounts supported by /account/balance
+const (
+	// SubAccountStaking holds the tokens delegated to validators
+	SubAccountStaking = "staking"
+	// SubAccountUnbonding holds the tokens being undelegated
+	SubAccountUnbonding = "unbonding"
+	// SubAccountRewards holds the delegation rewards which were not withdrawn yet
+	SubAccountRewards = "rewards"
+	// SubAccountLocked holds the tokens of a vesting account which are still locked by its
+	// vesting schedule and not delegated, it is empty for the other accounts
+	SubAccountLocked = "locked"
+	// SubAccountSpendable holds the tokens the account can transfer, which for vesting
+	// accounts excludes the locked tokens
+	SubAccountSpendable = "spendable"
+	// SubAccountValidatorMetadata is the sub account metadata key restricting
+	// the balance to the delegations to a single validator
+	SubAccountValidatorMetadata = "validator_address"
+)
+
+// SubAccountBalances fetches the balance of a sub account of the given address at height, the
+// balance can be restricted to a single validator through the SubAccountValidatorMetadata metadata
+func (c *Client) SubAccountBalances(ctx context.Context, addr string, subAccount *rosettatypes.SubAccountIdentifier, height *int64, currencies []*rosettatypes.Currency) ([]*rosettatypes.Amount, error) {
+	var validator string
+	if v, ok := subAccount.Metadata[SubAccountValidatorMetadata]; ok {
+		validator, ok = v.(string)
+		if !ok {
+			return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("%s metadata must be a string", SubAccountValidatorMetadata))
+		}
+	}
+
+	if height != nil {
+		strHeight := strconv.FormatInt(*height, 10)
+		ctx = metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strHeight)
+	}
+
+	n, err := c.nodeAt(height)
+	if err != nil {
+		return nil, err
+	}
+	var balances sdk.Coins
+	switch subAccount.Address {
+	case SubAccountStaking:
+		balances, err = c.delegatedBalances(ctx, n, addr, validator)
+	case SubAccountUnbonding:
+		balances, err = c.unbondingBalances(ctx, n, addr, validator)
+	case SubAccountRewards:
+		balances, err = c.rewardBalances(ctx, n, addr, validator)
+	case SubAccountLocked, SubAccountSpendable:
+		if validator != "" {
+			return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("%s metadata is not supported by the %s sub account", SubAccountValidatorMetadata, subAccount.Address))
+		}
+		if subAccount.Address == SubAccountLocked {
+			balances, err = c.lockedBalances(ctx, n, addr, height)
+		} else {
+			balances, err = c.spendableBalances(ctx, n, addr)
+		}
+	default:
+		return nil, crgerrs.WrapError(crgerrs.ErrBadArgument, fmt.Sprintf("unsupported sub account %s", subAccount.Address))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return filterAmounts(c.converter.ToRosetta().Amounts(balances, balances), currencies, c.Currency), nil
+}
+
+// bondDenom returns the staking denom, reported with a zero amount when nothing is delegated
+func (c *Client) bondDenom(ctx context.Context, n *node) (string, error) {
+	params, err := n.staking.Params(ctx, &stakingv1beta1.QueryParamsRequest{})
+	if err != nil {
+		return "", crgerrs.FromGRPCToRosettaError(err)
+	}
+	return params.GetParams().GetBondDenom(), nil
+}
+
+// delegatedBalances sums the tokens delegated by the address, to the given validator only if not empty
+func (c *Client) delegatedBalances(ctx context.Context, n *node, addr, validator string) (sdk.Coins, error) {
+	denom, err := c.bondDenom(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	total := sdk.NewCoin(denom, sdkmath.ZeroInt())
+
+	var nextKey []byte
+	for {
+		res, err := n.staking.DelegatorDelegations(ctx, &stakingv1beta1.QueryDelegatorDelegationsRequest{
+			DelegatorAddr: addr,
+			Pagination:    &queryv1beta1.PageRequest{Key: nextKey},
+		})
+		if err != nil {
+			return nil, crgerrs.FromGRPCToRosettaError(err)
+		}
+		for _, delegation := range res.DelegationResponses {
+			if validator != "" && delegation.GetDelegation().GetValidatorAddress() != validator {
+				continue
+			}
+			amount, err := parseInt(delegation.GetBalance().GetAmount())
+			if err != nil {
+				return nil, err
+			}
+			total.Amount = total.Amount.Add(amount)
+		}
+		nextKey = res.GetPagination().GetNextKey()
+		if len(nextKey) == 0 {
+			return sdk.Coins{total}, nil
+		}
+	}
+}
+
+// unbondingBalances sums the tokens being undelegated by the address, from the given validator only if not empty
+func (c *Client) unbondingBalances(ctx context.Context, n *node, addr, validator string) (sdk.Coins, error) {
+	denom, err := c.bondDenom(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	total := sdk.NewCoin(denom, sdkmath.ZeroInt())
+
+	var nextKey []byte
+	for {
+		res, err := n.staking.DelegatorUnbondingDelegations(ctx, &stakingv1beta1.QueryDelegatorUnbondingDelegationsRequest{
+			DelegatorAddr: addr,
+			Pagination:    &queryv1beta1.PageRequest{Key: nextKey},
+		})
+		if err != nil {
+			return nil, crgerrs.FromGRPCToRosettaError(err)
+		}
+		for _, unbonding := range res.UnbondingResponses {
+			if validator != "" && unbonding.ValidatorAddress != validator {
+				continue
+			}
+			for _, entry := range unbonding.Entries {
+				amount, err := parseInt(entry.Balance)
+				if err != nil {
+					return nil, err
+				}
+				total.Amount = total.Amount.Add(amount)
+			}
+		}
+		nextKey = res.GetPagination().GetNextKey()
+		if len(nextKey) == 0 {
+			return sdk.Coins{total}, nil
+		}
+	}
+}
+
+// rewardBalances returns the delegation rewards of the address, from the given validator only if not empty.
+// The rewards of each validator are withdrawn separately and truncated to integer amounts, so the rewards
+// are the sum of the truncated rewards of each validator, which is what the delegator receives.
+func (c *Client) rewardBalances(ctx context.Context, n *node, addr, validator string) (sdk.Coins, error) {
+	res, err := n.distribution.DelegationTotalRewards(ctx, &distributionv1beta1.QueryDelegationTotalRewardsRequest{
+		DelegatorAddress: addr,
+	})
+	if err != nil {
+		return nil, crgerrs.FromGRPCToRosettaError(err)
+	}
+	rewards := sdk.Coins{}
+	for _, reward := range res.Rewards {
+		if validator != "" && reward.ValidatorAddress != validator {
+			continue
+		}
+		coins, err := truncateDecCoins(reward.Reward)
+		if err != nil {
+			return nil, err
+		}
+		rewards = rewards.Add(coins...)
+	}
+	return rewards, nil
+}
+
+// lockedBalances returns the tokens of a vesting account locked at the time of the block at height
+func (c *Client) lockedBalances(ctx context.Context, n *node, addr string, height *int64) (sdk.Coins, error) {
+	accountInfo, err := n.auth.Account(ctx, &auth.QueryAccountRequest{Address: addr})
+	switch {
+	case status.Code(err) == codes.NotFound:
+		// the account does not exist yet, nothing is locked
+		return sdk.Coins{}, nil
+	case err != nil:
+		return nil, crgerrs.FromGRPCToRosettaError(err)
+	}
+
+	if height != nil && *height <= 0 {
+		height = nil
+	}
+	header, err := n.tmRPC.Header(ctx, height)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting block header %s", err.Error()))
+	}
+
+	locked, _, err := c.converter.ToRosetta().LockedCoins(accountInfo.Account, header.Header.Time)
+	if err != nil {
+		return nil, err
+	}
+	return locked, nil
+}
+
+// spendableBalances fetches every page of the balances the address can transfer
+func (c *Client) spendableBalances(ctx context.Context, n *node, addr string) (sdk.Coins, error) {
+	var (
+		balances sdk.Coins
+		nextKey  []byte
+	)
+	for {
+		res, err := n.bank.SpendableBalances(ctx, &bank.QuerySpendableBalancesRequest{
+			Address:    addr,
+			Pagination: &query.PageRequest{Key: nextKey},
+		})
+		if err != nil {
+			return nil, crgerrs.FromGRPCToRosettaError(err)
+		}
+		balances = append(balances, res.Balances...)
+		nextKey = res.GetPagination().GetNextKey()
+		if len(nextKey) == 0 {
+			return balances, nil
+		}
+	}
+}
+
+// truncateDecCoins converts decimal coins to coins truncating their amount
+func truncateDecCoins(decCoins []*basev1beta1.DecCoin) (sdk.Coins, error) {
+	coins := sdk.Coins{}
+	for _, decCoin := range decCoins {
+		amount, err := sdkmath.LegacyNewDecFromStr(decCoin.Amount)
+		if err != nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrCodec, fmt.Sprintf("parsing amount of %s %s", decCoin.Denom, err.Error()))
+		}
+		coins = coins.Add(sdk.NewCoin(decCoin.Denom, amount.TruncateInt()))
+	}
+	return coins, nil
+}
+
+func parseInt(amount string) (sdkmath.Int, error) {
+	value, ok := sdkmath.NewIntFromString(amount)
+	if !ok {
+		return sdkmath.Int{}, crgerrs.WrapError(crgerrs.ErrCodec, fmt.Sprintf("invalid amount %s", amount))
+	}
+	return value, nil
+}
+
+// filterAmounts returns the amounts of the given currencies only, in the order of the currencies,
+// a currency without amount is reported as zero. The amounts are returned as is without currencies.
+func filterAmounts(amounts []*rosettatypes.Amount, currencies []*rosettatypes.Currency, currency func(denom string) *rosettatypes.Currency) []*rosettatypes.Amount {
+	if len(currencies) == 0 {
+		return amounts
+	}
+	bySymbol := make(map[string]*rosettatypes.Amount, len(amounts))
+	for _, amount := range amounts {
+		bySymbol[amount.Currency.Symbol] = amount
+	}
+	filtered := make([]*rosettatypes.Amount, len(currencies))
+	for i, c := range currencies {
+		amount, ok := bySymbol[c.Symbol]
+		if !ok {
+			amount = &rosettatypes.Amount{
+				Value:    sdkmath.ZeroInt().String(),
+				Currency: currency(c.Symbol),
+			}
+		}
+		filtered[i] = amount
+	}
+	return filtered
+}
@@ -0,0 +1,154 @@
+package rosetta
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	tmrpc "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	basev1beta1 "cosmossdk.io/api/cosmos/base/v1beta1"
+	distributionv1beta1 "cosmossdk.io/api/cosmos/distribution/v1beta1"
+	stakingv1beta1 "cosmossdk.io/api/cosmos/staking/v1beta1"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	auth "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+)
+
+const (
+	validatorA = "cosmosvaloper1a"
+	validatorB = "cosmosvaloper1b"
+)
+
+type stakingClient struct {
+	stakingv1beta1.QueryClient
+}
+
+func (stakingClient) Params(context.Context, *stakingv1beta1.QueryParamsRequest, ...grpc.CallOption) (*stakingv1beta1.QueryParamsResponse, error) {
+	return &stakingv1beta1.QueryParamsResponse{Params: &stakingv1beta1.Params{BondDenom: "stake"}}, nil
+}
+
+func (stakingClient) DelegatorDelegations(context.Context, *stakingv1beta1.QueryDelegatorDelegationsRequest, ...grpc.CallOption) (*stakingv1beta1.QueryDelegatorDelegationsResponse, error) {
+	return &stakingv1beta1.QueryDelegatorDelegationsResponse{DelegationResponses: []*stakingv1beta1.DelegationResponse{
+		{Delegation: &stakingv1beta1.Delegation{ValidatorAddress: validatorA}, Balance: &basev1beta1.Coin{Denom: "stake", Amount: "100"}},
+		{Delegation: &stakingv1beta1.Delegation{ValidatorAddress: validatorB}, Balance: &basev1beta1.Coin{Denom: "stake", Amount: "50"}},
+	}}, nil
+}
+
+func (stakingClient) DelegatorUnbondingDelegations(context.Context, *stakingv1beta1.QueryDelegatorUnbondingDelegationsRequest, ...grpc.CallOption) (*stakingv1beta1.QueryDelegatorUnbondingDelegationsResponse, error) {
+	return &stakingv1beta1.QueryDelegatorUnbondingDelegationsResponse{UnbondingResponses: []*stakingv1beta1.UnbondingDelegation{
+		{ValidatorAddress: validatorA, Entries: []*stakingv1beta1.UnbondingDelegationEntry{{Balance: "10"}, {Balance: "5"}}},
+	}}, nil
+}
+
+type distributionClient struct {
+	distributionv1beta1.QueryClient
+}
+
+func (distributionClient) DelegationTotalRewards(context.Context, *distributionv1beta1.QueryDelegationTotalRewardsRequest, ...grpc.CallOption) (*distributionv1beta1.QueryDelegationTotalRewardsResponse, error) {
+	return &distributionv1beta1.QueryDelegationTotalRewardsResponse{
+		Rewards: []*distributionv1beta1.DelegationDelegatorReward{
+			{ValidatorAddress: validatorA, Reward: []*basev1beta1.DecCoin{{Denom: "stake", Amount: "1.900000000000000000"}}},
+			{ValidatorAddress: validatorB, Reward: []*basev1beta1.DecCoin{{Denom: "stake", Amount: "2.500000000000000000"}}},
+		},
+		Total: []*basev1beta1.DecCoin{{Denom: "stake", Amount: "4.400000000000000000"}},
+	}, nil
+}
+
+type vestingAuthClient struct {
+	auth.QueryClient
+	account *codectypes.Any
+}
+
+func (c vestingAuthClient) Account(context.Context, *auth.QueryAccountRequest, ...grpc.CallOption) (*auth.QueryAccountResponse, error) {
+	if c.account == nil {
+		return nil, status.Error(codes.NotFound, "account not found")
+	}
+	return &auth.QueryAccountResponse{Account: c.account}, nil
+}
+
+// headerClient serves block headers with the height as unix time
+type headerClient struct {
+	tmrpc.Client
+}
+
+func (headerClient) Header(_ context.Context, height *int64) (*coretypes.ResultHeader, error) {
+	return &coretypes.ResultHeader{Header: &cmttypes.Header{Height: *height, Time: time.Unix(*height, 0)}}, nil
+}
+
+func TestClient_SubAccountBalances_vesting(t *testing.T) {
+	vestingAcc, err := vestingtypes.NewContinuousVestingAccount(
+		auth.NewBaseAccountWithAddress(sdk.AccAddress("test")), sdk.NewCoins(sdk.NewInt64Coin("stake", 100)), 1000, 2000)
+	require.NoError(t, err)
+	anyAcc, err := codectypes.NewAnyWithValue(vestingAcc)
+	require.NoError(t, err)
+
+	authClient := &vestingAuthClient{account: &codectypes.Any{TypeUrl: anyAcc.TypeUrl, Value: anyAcc.Value}}
+	client := newTestClient(t, &Config{}, &node{auth: authClient, tmRPC: headerClient{}})
+	locked := func(height int64) []*rosettatypes.Amount {
+		amounts, err := client.SubAccountBalances(context.Background(), "cosmos1address", &rosettatypes.SubAccountIdentifier{Address: SubAccountLocked}, &height, nil)
+		require.NoError(t, err)
+		return amounts
+	}
+
+	require.Equal(t, []*rosettatypes.Amount{{Value: "75", Currency: &rosettatypes.Currency{Symbol: "stake"}}}, locked(1250))
+	require.Empty(t, locked(2000))
+
+	// the account does not exist yet
+	authClient.account = nil
+	require.Empty(t, locked(1250))
+}
+
+func TestClient_SubAccountBalances(t *testing.T) {
+	client := newTestClient(t, &Config{}, &node{staking: stakingClient{}, distribution: distributionClient{}})
+	ctx := context.Background()
+	height := int64(10)
+	stake := func(value string) []*rosettatypes.Amount {
+		return []*rosettatypes.Amount{{Value: value, Currency: &rosettatypes.Currency{Symbol: "stake"}}}
+	}
+	balances := func(subAccount *rosettatypes.SubAccountIdentifier, currencies ...*rosettatypes.Currency) []*rosettatypes.Amount {
+		amounts, err := client.SubAccountBalances(ctx, "cosmos1address", subAccount, &height, currencies)
+		require.NoError(t, err)
+		return amounts
+	}
+	ofValidator := map[string]interface{}{SubAccountValidatorMetadata: validatorB}
+
+	require.Equal(t, stake("150"), balances(&rosettatypes.SubAccountIdentifier{Address: SubAccountStaking}))
+	require.Equal(t, stake("50"), balances(&rosettatypes.SubAccountIdentifier{Address: SubAccountStaking, Metadata: ofValidator}))
+	require.Equal(t, stake("15"), balances(&rosettatypes.SubAccountIdentifier{Address: SubAccountUnbonding}))
+	require.Equal(t, stake("0"), balances(&rosettatypes.SubAccountIdentifier{Address: SubAccountUnbonding, Metadata: ofValidator}))
+	// rewards are truncated per validator, as they are withdrawn
+	require.Equal(t, stake("3"), balances(&rosettatypes.SubAccountIdentifier{Address: SubAccountRewards}))
+	require.Equal(t, stake("2"), balances(&rosettatypes.SubAccountIdentifier{Address: SubAccountRewards, Metadata: ofValidator}))
+
+	// currencies filter
+	require.Equal(t, []*rosettatypes.Amount{{Value: "0", Currency: &rosettatypes.Currency{Symbol: "uatom"}}},
+		balances(&rosettatypes.SubAccountIdentifier{Address: SubAccountStaking}, &rosettatypes.Currency{Symbol: "uatom"}))
+
+	_, err := client.SubAccountBalances(ctx, "cosmos1address", &rosettatypes.SubAccountIdentifier{Address: "unknown"}, &height, nil)
+	require.Error(t, err)
+	_, err = client.SubAccountBalances(ctx, "cosmos1address", &rosettatypes.SubAccountIdentifier{
+		Address:  SubAccountStaking,
+		Metadata: map[string]interface{}{SubAccountValidatorMetadata: 1},
+	}, &height, nil)
+	require.Error(t, err)
+}
+
+func TestClient_BalanceExemptions(t *testing.T) {
+	client := newTestClient(t, &Config{}, &node{})
+	var subAccounts []string
+	for _, exemption := range client.BalanceExemptions() {
+		require.Equal(t, rosettatypes.BalanceDynamic, exemption.ExemptionType)
+		subAccounts = append(subAccounts, *exemption.SubAccountAddress)
+	}
+	require.ElementsMatch(t, []string{SubAccountStaking, SubAccountUnbonding, SubAccountRewards, SubAccountLocked, SubAccountSpendable}, subAccounts)
+}
@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -28,6 +31,9 @@ func RosettaCommand(ir codectypes.InterfaceRegistry, cdc codec.Codec) *cobra.Com
 				return fmt.Errorf("exoected *codec.ProtoMarshaler, got: %T", cdc)
 			}
 			conf.WithCodec(ir, protoCodec)
+			if conf.InsecureHeaders {
+				fmt.Println("[Rosetta]- Warning: the node headers are sent in plaintext to the endpoints without TLS")
+			}
 
 			pluginPath := cmd.Flag(rosetta.FlagPlugin).Value.String()
 			typesServer := cmd.Flag(rosetta.FlagGRPCTypesServerEndpoint).Value.String()
@@ -38,7 +44,7 @@ func RosettaCommand(ir codectypes.InterfaceRegistry, cdc codec.Codec) *cobra.Com
 					return err
 				}
 			} else if typesServer != "" {
-				err = rosetta.ReflectInterfaces(ir, typesServer)
+				err = conf.ReflectInterfaces(ir, typesServer)
 				if err != nil {
 					fmt.Printf("[Rosetta]- Error while reflecting from gRPC server: %s", err.Error())
 					return err
@@ -50,7 +56,12 @@ func RosettaCommand(ir codectypes.InterfaceRegistry, cdc codec.Codec) *cobra.Com
 				fmt.Printf("[Rosetta]- Error while creating server: %s", err.Error())
 				return err
 			}
-			return rosettaSrv.Start()
+
+			// stop the server gracefully on SIGINT and SIGTERM
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return rosettaSrv.Start(ctx)
 		},
 	}
 	rosetta.SetFlags(cmd.Flags())
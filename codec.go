@@ -13,19 +13,30 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
 	authcodec "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingcodec "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
 )
 
 // MakeCodec generates the codec required to interact
 // with the cosmos APIs used by the rosetta gateway
 func MakeCodec() (*codec.ProtoCodec, codectypes.InterfaceRegistry) {
+	return makeCodec(sdk.GetConfig().GetBech32AccountAddrPrefix(), sdk.GetConfig().GetBech32ValidatorAddrPrefix())
+}
+
+// MakeCodecWithBech32Prefix generates the codec required to interact with the cosmos
+// APIs of a chain using the given account bech32 prefix, instead of the global sdk one
+func MakeCodecWithBech32Prefix(bech32Prefix string) (*codec.ProtoCodec, codectypes.InterfaceRegistry) {
+	return makeCodec(bech32Prefix, sdk.GetBech32PrefixValAddr(bech32Prefix))
+}
+
+func makeCodec(accPrefix, valPrefix string) (*codec.ProtoCodec, codectypes.InterfaceRegistry) {
 	ir, _ := codectypes.NewInterfaceRegistryWithOptions(codectypes.InterfaceRegistryOptions{
 		ProtoFiles: proto.HybridResolver,
 		SigningOptions: signing.Options{
 			AddressCodec: address.Bech32Codec{
-				Bech32Prefix: sdk.GetConfig().GetBech32AccountAddrPrefix(),
+				Bech32Prefix: accPrefix,
 			},
 			ValidatorAddressCodec: address.Bech32Codec{
-				Bech32Prefix: sdk.GetConfig().GetBech32ValidatorAddrPrefix(),
+				Bech32Prefix: valPrefix,
 			},
 		},
 	})
@@ -33,6 +44,7 @@ func MakeCodec() (*codec.ProtoCodec, codectypes.InterfaceRegistry) {
 
 	sdk.RegisterInterfaces(ir)
 	authcodec.RegisterInterfaces(ir)
+	vestingcodec.RegisterInterfaces(ir)
 	bankcodec.RegisterInterfaces(ir)
 	cryptocodec.RegisterInterfaces(ir)
 	txtypes.RegisterInterfaces(ir)
@@ -2,6 +2,8 @@ package rosetta
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
@@ -49,6 +51,16 @@ const (
 	DenomToSuggest = "uatom"
 	// DefaultPrices defines the default list of prices to suggest
 	DefaultPrices = "1uatom,1stake"
+	// DefaultShutdownTimeout defines the default time given to in-flight requests to complete on shutdown
+	DefaultShutdownTimeout = 30 * time.Second
+	// DefaultHealthCheckInterval defines the default time between two node health checks
+	DefaultHealthCheckInterval = 10 * time.Second
+	// DefaultTracingSampleRatio defines the default ratio of the traced requests
+	DefaultTracingSampleRatio = 1.0
+	// DefaultCircuitBreakerThreshold defines the default number of failed health checks after which the node circuit opens
+	DefaultCircuitBreakerThreshold = 3
+	// DefaultDenomMetadataRefresh defines the default time between two queries of the denom metadata
+	DefaultDenomMetadataRefresh = 10 * time.Minute
 )
 
 // configuration flags
@@ -67,6 +79,33 @@ const (
 	FlagPricesToSuggest         = "prices-to-suggest"
 	FlagPlugin                  = "plugin"
 	FlagBech32Prefix            = "bech32-prefix"
+	FlagShutdownTimeout         = "shutdown-timeout"
+	FlagTLSCert                 = "tls-cert"
+	FlagTLSKey                  = "tls-key"
+	FlagTLSClientCA             = "tls-client-ca"
+	FlagMetricsAddr             = "metrics-addr"
+	FlagNetworksFile            = "networks-file"
+	FlagHealthCheckInterval     = "health-check-interval"
+	FlagTracingEndpoint         = "tracing-endpoint"
+	FlagTracingInsecure         = "tracing-insecure"
+	FlagTracingSampleRatio      = "tracing-sample-ratio"
+	FlagCacheSize               = "cache-size"
+	FlagCacheDir                = "cache-dir"
+	FlagCircuitBreakerThreshold = "circuit-breaker-threshold"
+	FlagArchiveTendermint       = "archive-tendermint"
+	FlagArchiveGRPC             = "archive-grpc"
+	FlagPruningWindow           = "pruning-window"
+	FlagNodeTLS                 = "node-tls"
+	FlagNodeCA                  = "node-ca"
+	FlagGRPCHeader              = "grpc-header"
+	FlagTendermintHeader        = "tendermint-header"
+	FlagInsecureHeaders         = "insecure-headers"
+	FlagHeldBalancesOnly        = "held-balances-only"
+	FlagEventsDir               = "events-dir"
+	FlagDenomMetadataFile       = "denom-metadata-file"
+	FlagDenomMetadataRefresh    = "denom-metadata-refresh-interval"
+	FlagDenomTracesDir          = "denom-traces-dir"
+	FlagRawOperationTypes       = "raw-operation-types"
 )
 
 // Config defines the configuration of the rosetta server
@@ -79,9 +118,12 @@ type Config struct {
 	// TendermintRPC defines the endpoint to connect to
 	// CometBFT RPC, specifying 'tcp://' before is not
 	// required, usually it's at port 26657 of the
+	// node. Several comma separated endpoints can be
+	// provided to fail over between nodes.
 	TendermintRPC string
 	// GRPCEndpoint defines the cosmos application gRPC endpoint
-	// usually it is located at 9090 port
+	// usually it is located at 9090 port, comma separated
+	// endpoints are paired in order with the TendermintRPC ones
 	GRPCEndpoint string
 	// Addr defines the default address to bind the rosetta server to
 	// defaults to DefaultAddr
@@ -105,6 +147,82 @@ type Config struct {
 	InterfaceRegistry codectypes.InterfaceRegistry
 	// Bech32Prefix defines the prefix used for bech32 addresses in the network.
 	Bech32Prefix string
+	// ShutdownTimeout defines the maximum time given to in-flight requests
+	// to complete when the server is stopped
+	ShutdownTimeout time.Duration
+	// TLSCertFile defines the certificate used to serve the rosetta API over HTTPS,
+	// it is reloaded when rotated on disk
+	TLSCertFile string
+	// TLSKeyFile defines the private key of TLSCertFile
+	TLSKeyFile string
+	// TLSClientCAFile defines the CA bundle used to verify client certificates,
+	// when set clients are required to authenticate with mutual TLS
+	TLSClientCAFile string
+	// MetricsAddr defines the address to serve the prometheus metrics at,
+	// metrics are disabled when empty
+	MetricsAddr string
+	// Networks defines additional networks served by the same rosetta instance,
+	// requests are routed to each network by their network identifier
+	Networks []NetworkConfig
+	// HealthCheckInterval defines the time between two checks of the node health
+	// reported at /healthz and /readyz
+	HealthCheckInterval time.Duration
+	// TracingEndpoint defines the OTLP gRPC endpoint the traces are exported to,
+	// tracing is disabled when empty
+	TracingEndpoint string
+	// TracingInsecure disables TLS towards the tracing endpoint
+	TracingInsecure bool
+	// TracingSampleRatio defines the ratio of the rosetta requests which are traced, between 0 and 1,
+	// 0 traces only the requests propagating a sampled trace
+	TracingSampleRatio float64
+	// CacheSize defines the number of blocks and of transactions below the tip
+	// of the chain kept in memory, the cache is disabled when 0
+	CacheSize int
+	// CacheDir defines the directory where the cached blocks and transactions
+	// are persisted across restarts, the disk cache is disabled when empty
+	CacheDir string
+	// CircuitBreakerThreshold defines the number of consecutive failed health checks after
+	// which requests needing the node fail fast with a retriable error until it recovers
+	CircuitBreakerThreshold int
+	// ArchiveTendermintRPC defines the CometBFT RPC endpoint of an archive node keeping the full
+	// history, the queries at heights pruned by the other nodes are sent to it. Disabled if empty.
+	ArchiveTendermintRPC string
+	// ArchiveGRPCEndpoint defines the gRPC endpoint of the archive node
+	ArchiveGRPCEndpoint string
+	// PruningWindow defines the number of recent blocks kept by the nodes, the queries at older
+	// heights are sent to the archive node. If 0 only the earliest height reported by the nodes is used.
+	PruningWindow int64
+	// NodeTLS enables TLS towards the gRPC endpoints, the CometBFT RPC endpoints
+	// use TLS when their scheme is https, as do gRPC endpoints prefixed with https://
+	NodeTLS bool
+	// NodeCAFile defines the CA bundle verifying the certificates of the node endpoints,
+	// the system roots are used if empty
+	NodeCAFile string
+	// GRPCHeaders defines the headers sent with every gRPC query, such as an authorization token
+	GRPCHeaders map[string]string
+	// TendermintHeaders defines the headers sent with every CometBFT RPC request
+	TendermintHeaders map[string]string
+	// InsecureHeaders allows sending the gRPC and CometBFT headers to endpoints without TLS,
+	// otherwise the queries carrying headers to such endpoints fail
+	InsecureHeaders bool
+	// HeldBalancesOnly makes /account/balance return only the denoms held by the account,
+	// instead of every denom of the total supply with the denoms not held set to zero
+	HeldBalancesOnly bool
+	// EventsDir defines the directory where the mapping of the /events/blocks sequences to block
+	// heights is persisted across restarts, it defaults to a directory next to the disk cache.
+	// /events/blocks is not served without either of them, as the sequences would not be stable.
+	EventsDir string
+	// DenomMetadataFile defines a json file listing bank denom metadata, it overrides the metadata
+	// of the chain which fills the decimals of the currencies, for chains without on chain metadata
+	DenomMetadataFile string
+	// DenomMetadataRefresh defines the time between two queries of the denom metadata of the chain
+	DenomMetadataRefresh time.Duration
+	// DenomTracesDir defines the directory where the resolved ibc denom traces are persisted
+	// across restarts, they are kept in memory when empty
+	DenomTracesDir string
+	// RawOperationTypes keeps the event types (coin_spent, coin_received, burn) as the types of the
+	// balance operations, instead of classifying the fee, mint, reward, staking and gov operations
+	RawOperationTypes bool
 }
 
 // NetworkIdentifier returns the network identifier given the configuration
@@ -115,6 +233,35 @@ func (c *Config) NetworkIdentifier() *types.NetworkIdentifier {
 	}
 }
 
+// cacheDir returns the directory of the network disk cache, empty if disabled
+func (c *Config) cacheDir() string {
+	if c.CacheDir == "" {
+		return ""
+	}
+	return filepath.Join(c.CacheDir, c.Blockchain, c.Network)
+}
+
+// eventsDir returns the directory of the network events store, next to the disk cache
+// if not set, empty if neither the events directory nor the disk cache are set
+func (c *Config) eventsDir() string {
+	switch {
+	case c.EventsDir != "":
+		return filepath.Join(c.EventsDir, c.Blockchain, c.Network)
+	case c.CacheDir != "":
+		return filepath.Join(c.CacheDir, c.Blockchain, c.Network+"-events")
+	default:
+		return ""
+	}
+}
+
+// denomTracesDir returns the directory of the network denom traces store, empty if kept in memory
+func (c *Config) denomTracesDir() string {
+	if c.DenomTracesDir == "" {
+		return ""
+	}
+	return filepath.Join(c.DenomTracesDir, c.Blockchain, c.Network)
+}
+
 // validate validates a configuration and sets
 // its defaults in case they were not provided
 func (c *Config) validate() error {
@@ -131,6 +278,18 @@ func (c *Config) validate() error {
 	if c.Retries == 0 {
 		c.Retries = DefaultRetries
 	}
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if c.HealthCheckInterval == 0 {
+		c.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+	if c.CircuitBreakerThreshold == 0 {
+		c.CircuitBreakerThreshold = DefaultCircuitBreakerThreshold
+	}
+	if c.DenomMetadataRefresh == 0 {
+		c.DenomMetadataRefresh = DefaultDenomMetadataRefresh
+	}
 	// these are must
 	if c.Network == "" {
 		return crgerrs.WrapError(crgerrs.ErrConfig, "network not provided")
@@ -158,15 +317,87 @@ func (c *Config) validate() error {
 	if c.TendermintRPC == "" {
 		return crgerrs.WrapError(crgerrs.ErrConfig, "cometbft rpc not provided")
 	}
-	validatedURL, err := c.validateURL(c.TendermintRPC)
-	if err != nil {
-		return err
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "tls certificate and key must be both provided")
+	}
+	if c.TLSClientCAFile != "" && c.TLSCertFile == "" {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "tls client ca requires a tls certificate and key")
+	}
+	if c.CacheSize < 0 {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "cache size must not be negative")
+	}
+	if c.CacheDir != "" && c.CacheSize == 0 {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "disk cache requires a cache size")
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "tracing sample ratio must be between 0 and 1")
+	}
+	tendermintRPCs, grpcEndpoints := splitEndpoints(c.TendermintRPC), splitEndpoints(c.GRPCEndpoint)
+	if len(tendermintRPCs) == 0 {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "cometbft rpc not provided")
+	}
+	if len(tendermintRPCs) != len(grpcEndpoints) {
+		return crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("got %d cometbft rpc endpoints and %d grpc endpoints, each node requires both", len(tendermintRPCs), len(grpcEndpoints)))
+	}
+	for i, tendermintRPC := range tendermintRPCs {
+		validatedURL, err := c.validateURL(tendermintRPC)
+		if err != nil {
+			return err
+		}
+		tendermintRPCs[i] = validatedURL
+	}
+	c.TendermintRPC = strings.Join(tendermintRPCs, ",")
+	c.GRPCEndpoint = strings.Join(grpcEndpoints, ",")
+
+	if (c.ArchiveTendermintRPC == "") != (c.ArchiveGRPCEndpoint == "") {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "archive cometbft rpc and grpc endpoints must be both provided")
+	}
+	if c.PruningWindow < 0 {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "pruning window must not be negative")
+	}
+	if c.PruningWindow > 0 && c.ArchiveTendermintRPC == "" {
+		return crgerrs.WrapError(crgerrs.ErrConfig, "pruning window requires an archive node")
+	}
+	if c.ArchiveTendermintRPC != "" {
+		validatedURL, err := c.validateURL(c.ArchiveTendermintRPC)
+		if err != nil {
+			return err
+		}
+		c.ArchiveTendermintRPC = validatedURL
 	}
-	c.TendermintRPC = validatedURL
 
 	return nil
 }
 
+// nodeEndpoints returns the endpoints of each node of the network
+func (c *Config) nodeEndpoints() []nodeEndpoints {
+	tendermintRPCs, grpcEndpoints := splitEndpoints(c.TendermintRPC), splitEndpoints(c.GRPCEndpoint)
+	endpoints := make([]nodeEndpoints, len(tendermintRPCs))
+	for i := range tendermintRPCs {
+		endpoints[i] = nodeEndpoints{grpc: grpcEndpoints[i], tendermintRPC: tendermintRPCs[i]}
+	}
+	return endpoints
+}
+
+// archiveEndpoints returns the endpoints of the archive node, nil if not configured
+func (c *Config) archiveEndpoints() *nodeEndpoints {
+	if c.ArchiveTendermintRPC == "" {
+		return nil
+	}
+	return &nodeEndpoints{grpc: c.ArchiveGRPCEndpoint, tendermintRPC: c.ArchiveTendermintRPC}
+}
+
+// splitEndpoints splits a comma separated list of endpoints
+func splitEndpoints(endpoints string) []string {
+	var split []string
+	for _, endpoint := range strings.Split(endpoints, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			split = append(split, endpoint)
+		}
+	}
+	return split
+}
+
 func (c *Config) validateURL(tendermintRPC string) (string, error) {
 	u, err := url.Parse(tendermintRPC)
 	if err != nil {
@@ -241,6 +472,129 @@ func FromFlags(flags *pflag.FlagSet) (*Config, error) {
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting bech32Prefix flag %s", err.Error()))
 	}
+	shutdownTimeout, err := flags.GetDuration(FlagShutdownTimeout)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting shutdownTimeout flag %s", err.Error()))
+	}
+	tlsCertFile, err := flags.GetString(FlagTLSCert)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting tlsCert flag %s", err.Error()))
+	}
+	tlsKeyFile, err := flags.GetString(FlagTLSKey)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting tlsKey flag %s", err.Error()))
+	}
+	tlsClientCAFile, err := flags.GetString(FlagTLSClientCA)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting tlsClientCA flag %s", err.Error()))
+	}
+	metricsAddr, err := flags.GetString(FlagMetricsAddr)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting metricsAddr flag %s", err.Error()))
+	}
+	networksFile, err := flags.GetString(FlagNetworksFile)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting networksFile flag %s", err.Error()))
+	}
+	healthCheckInterval, err := flags.GetDuration(FlagHealthCheckInterval)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting healthCheckInterval flag %s", err.Error()))
+	}
+	tracingEndpoint, err := flags.GetString(FlagTracingEndpoint)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting tracingEndpoint flag %s", err.Error()))
+	}
+	tracingInsecure, err := flags.GetBool(FlagTracingInsecure)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting tracingInsecure flag %s", err.Error()))
+	}
+	tracingSampleRatio, err := flags.GetFloat64(FlagTracingSampleRatio)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting tracingSampleRatio flag %s", err.Error()))
+	}
+	cacheSize, err := flags.GetInt(FlagCacheSize)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting cacheSize flag %s", err.Error()))
+	}
+	cacheDir, err := flags.GetString(FlagCacheDir)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting cacheDir flag %s", err.Error()))
+	}
+	eventsDir, err := flags.GetString(FlagEventsDir)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting eventsDir flag %s", err.Error()))
+	}
+	circuitBreakerThreshold, err := flags.GetInt(FlagCircuitBreakerThreshold)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting circuitBreakerThreshold flag %s", err.Error()))
+	}
+	archiveTendermintRPC, err := flags.GetString(FlagArchiveTendermint)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting archiveTendermint flag %s", err.Error()))
+	}
+	archiveGRPCEndpoint, err := flags.GetString(FlagArchiveGRPC)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting archiveGRPC flag %s", err.Error()))
+	}
+	pruningWindow, err := flags.GetInt64(FlagPruningWindow)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting pruningWindow flag %s", err.Error()))
+	}
+	nodeTLS, err := flags.GetBool(FlagNodeTLS)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting nodeTLS flag %s", err.Error()))
+	}
+	nodeCAFile, err := flags.GetString(FlagNodeCA)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting nodeCA flag %s", err.Error()))
+	}
+	grpcHeaderFlags, err := flags.GetStringArray(FlagGRPCHeader)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting grpcHeader flag %s", err.Error()))
+	}
+	grpcHeaders, err := parseHeaders(grpcHeaderFlags)
+	if err != nil {
+		return nil, err
+	}
+	tendermintHeaderFlags, err := flags.GetStringArray(FlagTendermintHeader)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting tendermintHeader flag %s", err.Error()))
+	}
+	tendermintHeaders, err := parseHeaders(tendermintHeaderFlags)
+	if err != nil {
+		return nil, err
+	}
+	insecureHeaders, err := flags.GetBool(FlagInsecureHeaders)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting insecureHeaders flag %s", err.Error()))
+	}
+	heldBalancesOnly, err := flags.GetBool(FlagHeldBalancesOnly)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting heldBalancesOnly flag %s", err.Error()))
+	}
+	denomMetadataFile, err := flags.GetString(FlagDenomMetadataFile)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting denomMetadataFile flag %s", err.Error()))
+	}
+	denomMetadataRefresh, err := flags.GetDuration(FlagDenomMetadataRefresh)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting denomMetadataRefresh flag %s", err.Error()))
+	}
+	denomTracesDir, err := flags.GetString(FlagDenomTracesDir)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting denomTracesDir flag %s", err.Error()))
+	}
+	rawOperationTypes, err := flags.GetBool(FlagRawOperationTypes)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while getting rawOperationTypes flag %s", err.Error()))
+	}
+	var networks []NetworkConfig
+	if networksFile != "" {
+		networks, err = LoadNetworksFile(networksFile)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var prices sdk.DecCoins
 	if enableDefaultFeeSuggestion {
@@ -255,18 +609,45 @@ func FromFlags(flags *pflag.FlagSet) (*Config, error) {
 	}
 
 	conf := &Config{
-		Blockchain:          blockchain,
-		Network:             network,
-		TendermintRPC:       tendermintRPC,
-		GRPCEndpoint:        gRPCEndpoint,
-		Addr:                addr,
-		Retries:             retries,
-		Offline:             offline,
-		EnableFeeSuggestion: enableDefaultFeeSuggestion,
-		GasToSuggest:        gasToSuggest,
-		DenomToSuggest:      denomToSuggest,
-		GasPrices:           prices,
-		Bech32Prefix:        bech32Prefix,
+		Blockchain:              blockchain,
+		Network:                 network,
+		TendermintRPC:           tendermintRPC,
+		GRPCEndpoint:            gRPCEndpoint,
+		Addr:                    addr,
+		Retries:                 retries,
+		Offline:                 offline,
+		EnableFeeSuggestion:     enableDefaultFeeSuggestion,
+		GasToSuggest:            gasToSuggest,
+		DenomToSuggest:          denomToSuggest,
+		GasPrices:               prices,
+		Bech32Prefix:            bech32Prefix,
+		ShutdownTimeout:         shutdownTimeout,
+		TLSCertFile:             tlsCertFile,
+		TLSKeyFile:              tlsKeyFile,
+		TLSClientCAFile:         tlsClientCAFile,
+		MetricsAddr:             metricsAddr,
+		Networks:                networks,
+		HealthCheckInterval:     healthCheckInterval,
+		TracingEndpoint:         tracingEndpoint,
+		TracingInsecure:         tracingInsecure,
+		TracingSampleRatio:      tracingSampleRatio,
+		CacheSize:               cacheSize,
+		CacheDir:                cacheDir,
+		CircuitBreakerThreshold: circuitBreakerThreshold,
+		ArchiveTendermintRPC:    archiveTendermintRPC,
+		ArchiveGRPCEndpoint:     archiveGRPCEndpoint,
+		PruningWindow:           pruningWindow,
+		NodeTLS:                 nodeTLS,
+		NodeCAFile:              nodeCAFile,
+		GRPCHeaders:             grpcHeaders,
+		TendermintHeaders:       tendermintHeaders,
+		InsecureHeaders:         insecureHeaders,
+		HeldBalancesOnly:        heldBalancesOnly,
+		EventsDir:               eventsDir,
+		DenomMetadataFile:       denomMetadataFile,
+		DenomMetadataRefresh:    denomMetadataRefresh,
+		DenomTracesDir:          denomTracesDir,
+		RawOperationTypes:       rawOperationTypes,
 	}
 	err = conf.validate()
 	if err != nil {
@@ -284,17 +665,52 @@ func ServerFromConfig(conf *Config) (crg.Server, error) {
 	if err != nil {
 		return crg.Server{}, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while creating a new client from configs %s", err.Error()))
 	}
+	additionalNetworks := make([]crg.Network, 0, len(conf.Networks))
+	// closeClients releases the clients already created, which hold the locks of their stores
+	closeClients := func() {
+		_ = client.Close()
+		for _, network := range additionalNetworks {
+			_ = network.Client.Close()
+		}
+	}
+	for _, network := range conf.Networks {
+		networkConf, err := conf.networkConfig(network)
+		if err != nil {
+			closeClients()
+			return crg.Server{}, err
+		}
+		networkClient, err := NewClient(networkConf)
+		if err != nil {
+			closeClients()
+			return crg.Server{}, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("while creating a new client for network %s %s", network.Network, err.Error()))
+		}
+		additionalNetworks = append(additionalNetworks, crg.Network{
+			Identifier: networkConf.NetworkIdentifier(),
+			Client:     networkClient,
+		})
+	}
 	return crg.NewServer(
 		crg.Settings{
 			Network: &types.NetworkIdentifier{
 				Blockchain: conf.Blockchain,
 				Network:    conf.Network,
 			},
-			Client:    client,
-			Listen:    conf.Addr,
-			Offline:   conf.Offline,
-			Retries:   conf.Retries,
-			RetryWait: 15 * time.Second,
+			Client:                  client,
+			Listen:                  conf.Addr,
+			Offline:                 conf.Offline,
+			Retries:                 conf.Retries,
+			RetryWait:               15 * time.Second,
+			ShutdownTimeout:         conf.ShutdownTimeout,
+			TLSCertFile:             conf.TLSCertFile,
+			TLSKeyFile:              conf.TLSKeyFile,
+			TLSClientCAFile:         conf.TLSClientCAFile,
+			MetricsListen:           conf.MetricsAddr,
+			AdditionalNetworks:      additionalNetworks,
+			HealthCheckInterval:     conf.HealthCheckInterval,
+			TracingEndpoint:         conf.TracingEndpoint,
+			TracingInsecure:         conf.TracingInsecure,
+			TracingSampleRatio:      conf.TracingSampleRatio,
+			CircuitBreakerThreshold: conf.CircuitBreakerThreshold,
 		})
 }
 
@@ -302,8 +718,8 @@ func ServerFromConfig(conf *Config) (crg.Server, error) {
 func SetFlags(flags *pflag.FlagSet) {
 	flags.String(FlagBlockchain, DefaultBlockchain, "the blockchain type")
 	flags.String(FlagNetwork, DefaultNetwork, "the network name")
-	flags.String(FlagTendermintEndpoint, DefaultCometEndpoint, "the CometBFT rpc endpoint, without tcp://")
-	flags.String(FlagGRPCEndpoint, DefaultGRPCEndpoint, "the app gRPC endpoint")
+	flags.String(FlagTendermintEndpoint, DefaultCometEndpoint, "the CometBFT rpc endpoint, without tcp://, comma separated to fail over between several nodes")
+	flags.String(FlagGRPCEndpoint, DefaultGRPCEndpoint, "the app gRPC endpoint, comma separated in the same order as the CometBFT rpc endpoints")
 	flags.String(FlagGRPCTypesServerEndpoint, DefaultGRPCTypesServerEndpoint, "the app gRPC Server endpoint for proto messages types and reflection")
 	flags.String(FlagAddr, DefaultAddr, "the address rosetta will bind to")
 	flags.Int(FlagRetries, DefaultRetries, "the number of retries that will be done before quitting")
@@ -314,4 +730,31 @@ func SetFlags(flags *pflag.FlagSet) {
 	flags.String(FlagPricesToSuggest, DefaultPrices, "default prices for fee suggestion")
 	flags.String(FlagPlugin, "", "plugin folder name")
 	flags.String(FlagBech32Prefix, "cosmos", "address bech32 prefix")
+	flags.Duration(FlagShutdownTimeout, DefaultShutdownTimeout, "maximum time given to in-flight requests to complete on shutdown")
+	flags.String(FlagTLSCert, "", "certificate file used to serve rosetta over HTTPS, reloaded on change")
+	flags.String(FlagTLSKey, "", "private key file of the tls certificate")
+	flags.String(FlagTLSClientCA, "", "CA bundle used to require and verify client certificates (mutual TLS), the health probes do not require one")
+	flags.String(FlagMetricsAddr, "", "the address to serve prometheus metrics at, disabled if empty")
+	flags.Duration(FlagHealthCheckInterval, DefaultHealthCheckInterval, "time between two node health checks served at /healthz and /readyz")
+	flags.String(FlagTracingEndpoint, "", "the OTLP gRPC endpoint to export traces to (ex: localhost:4317), disabled if empty")
+	flags.Bool(FlagTracingInsecure, false, "connect to the tracing endpoint without TLS")
+	flags.Float64(FlagTracingSampleRatio, DefaultTracingSampleRatio, "ratio of the rosetta requests which are traced, between 0 and 1")
+	flags.Int(FlagCacheSize, 0, "number of blocks and transactions below the chain tip cached in memory, disabled if 0")
+	flags.String(FlagCacheDir, "", "directory where the cached blocks and transactions are persisted, disabled if empty")
+	flags.Int(FlagCircuitBreakerThreshold, DefaultCircuitBreakerThreshold, "number of consecutive failed health checks after which requests to the node fail fast and rosetta reconnects")
+	flags.String(FlagArchiveTendermint, "", "the CometBFT rpc endpoint of an archive node serving the heights pruned by the other nodes, disabled if empty")
+	flags.String(FlagArchiveGRPC, "", "the app gRPC endpoint of the archive node")
+	flags.Int64(FlagPruningWindow, 0, "number of recent blocks kept by the pruning nodes, older heights are queried from the archive node")
+	flags.Bool(FlagNodeTLS, false, "connect to the gRPC endpoints with TLS, CometBFT endpoints use TLS with the https scheme")
+	flags.String(FlagNodeCA, "", "CA bundle verifying the node certificates, the system roots are used if empty")
+	flags.StringArray(FlagGRPCHeader, nil, "header sent with every gRPC query as name=value (ex: authorization=Bearer token), can be repeated")
+	flags.StringArray(FlagTendermintHeader, nil, "header sent with every CometBFT rpc request as name=value, can be repeated")
+	flags.Bool(FlagInsecureHeaders, false, "allow sending the gRPC and CometBFT headers to endpoints without TLS, they are sent in plaintext")
+	flags.Bool(FlagHeldBalancesOnly, false, "return only the denoms held by the account in /account/balance, instead of every denom of the supply")
+	flags.String(FlagEventsDir, "", "directory where the sequences of /events/blocks are persisted, next to the cache dir if empty, /events/blocks is not served without either")
+	flags.String(FlagDenomMetadataFile, "", "json file listing bank denom metadata overriding the metadata of the chain, which fills the currency decimals")
+	flags.Duration(FlagDenomMetadataRefresh, DefaultDenomMetadataRefresh, "time between two queries of the denom metadata of the chain")
+	flags.String(FlagDenomTracesDir, "", "directory where the resolved ibc denom traces are persisted, kept in memory if empty")
+	flags.Bool(FlagRawOperationTypes, false, "type the balance operations with their event type instead of classifying the fee, mint, reward, staking and gov operations")
+	flags.String(FlagNetworksFile, "", "json file listing additional networks to serve, each with its own endpoints and bech32 prefix")
 }
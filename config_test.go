@@ -67,3 +67,122 @@ func TestConfig_validateUrl(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_networkConfig(t *testing.T) {
+	base := &Config{
+		Blockchain:    "cosmos",
+		Network:       "cosmoshub-4",
+		TendermintRPC: "localhost:26657",
+		GRPCEndpoint:  "localhost:9090",
+		GasToSuggest:  200000,
+		Bech32Prefix:  "cosmos",
+	}
+	cdc, ir := MakeCodec()
+	base.WithCodec(ir, cdc)
+	require.NoError(t, base.validate())
+
+	conf, err := base.networkConfig(NetworkConfig{
+		Network:       "osmosis-1",
+		TendermintRPC: "osmosis:26657",
+		GRPCEndpoint:  "osmosis:9090",
+		Bech32Prefix:  "osmo",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "cosmos", conf.Blockchain)
+	require.Equal(t, "osmosis-1", conf.Network)
+	require.Equal(t, "http://osmosis:26657", conf.TendermintRPC)
+	require.Equal(t, "osmo", conf.Bech32Prefix)
+	require.NotSame(t, base.Codec, conf.Codec)
+	// the main network configuration is left untouched
+	require.Equal(t, "cosmoshub-4", base.Network)
+
+	_, err = base.networkConfig(NetworkConfig{Network: "no-prefix", TendermintRPC: "localhost:26657", GRPCEndpoint: "localhost:9090"})
+	require.Error(t, err)
+}
+
+func TestConfig_tracingSampleRatio(t *testing.T) {
+	for ratio, valid := range map[float64]bool{0: true, 0.5: true, 1: true, -0.1: false, 1.1: false} {
+		conf := &Config{
+			Blockchain:         "cosmos",
+			Network:            "cosmoshub-4",
+			TendermintRPC:      "localhost:26657",
+			GRPCEndpoint:       "localhost:9090",
+			GasToSuggest:       200000,
+			Bech32Prefix:       "cosmos",
+			TracingSampleRatio: ratio,
+		}
+		cdc, ir := MakeCodec()
+		conf.WithCodec(ir, cdc)
+		if !valid {
+			require.Error(t, conf.validate(), ratio)
+			continue
+		}
+		// the ratio is kept as is, a zero ratio never samples the requests
+		require.NoError(t, conf.validate(), ratio)
+		require.Equal(t, ratio, conf.TracingSampleRatio)
+	}
+}
+
+func TestServerFromConfig_closesClients(t *testing.T) {
+	conf := &Config{
+		Blockchain:    "cosmos",
+		Network:       "cosmoshub-4",
+		TendermintRPC: "localhost:26657",
+		GRPCEndpoint:  "localhost:9090",
+		GasToSuggest:  200000,
+		Bech32Prefix:  "cosmos",
+		CacheSize:     10,
+		CacheDir:      t.TempDir(),
+		Networks:      []NetworkConfig{{Network: "no-prefix", TendermintRPC: "localhost:26657", GRPCEndpoint: "localhost:9090"}},
+	}
+	cdc, ir := MakeCodec()
+	conf.WithCodec(ir, cdc)
+	_, err := ServerFromConfig(conf)
+	require.Error(t, err)
+
+	// the stores of the clients already created were released
+	client, err := NewClient(conf)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+}
+
+func TestConfig_nodeEndpoints(t *testing.T) {
+	conf := &Config{
+		Blockchain:    "cosmos",
+		Network:       "cosmoshub-4",
+		GasToSuggest:  200000,
+		Bech32Prefix:  "cosmos",
+		TendermintRPC: "sentry-0:26657, sentry-1:26657",
+		GRPCEndpoint:  "sentry-0:9090,sentry-1:9090",
+	}
+	require.NoError(t, conf.validate())
+	require.Equal(t, "http://sentry-0:26657,http://sentry-1:26657", conf.TendermintRPC)
+	require.Equal(t, []nodeEndpoints{
+		{grpc: "sentry-0:9090", tendermintRPC: "http://sentry-0:26657"},
+		{grpc: "sentry-1:9090", tendermintRPC: "http://sentry-1:26657"},
+	}, conf.nodeEndpoints())
+
+	conf.GRPCEndpoint = "sentry-0:9090"
+	require.Error(t, conf.validate())
+}
+
+func TestConfig_archiveEndpoints(t *testing.T) {
+	conf := &Config{
+		Blockchain:    "cosmos",
+		Network:       "cosmoshub-4",
+		GasToSuggest:  200000,
+		Bech32Prefix:  "cosmos",
+		TendermintRPC: "localhost:26657",
+		GRPCEndpoint:  "localhost:9090",
+		PruningWindow: 100,
+	}
+	// the pruning window requires an archive node
+	require.Error(t, conf.validate())
+
+	conf.ArchiveTendermintRPC = "archive:26657"
+	require.Error(t, conf.validate())
+
+	conf.ArchiveGRPCEndpoint = "archive:9090"
+	require.NoError(t, conf.validate())
+	require.Equal(t, &nodeEndpoints{grpc: "archive:9090", tendermintRPC: "http://archive:26657"}, conf.archiveEndpoints())
+}
@@ -7,6 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
+	"time"
 
 	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
 	abci "github.com/cometbft/cometbft/abci/types"
@@ -28,6 +31,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	vestingexported "github.com/cosmos/cosmos-sdk/x/auth/vesting/exported"
 
 	crgerrs "github.com/cosmos/rosetta/lib/errors"
 	crgtypes "github.com/cosmos/rosetta/lib/types"
@@ -54,8 +58,8 @@ type Converter interface {
 // all the functions used to convert sdk and
 // CometBFT types to rosetta known types
 type ToRosettaConverter interface {
-	// BlockResponse returns a block response given a result block
-	BlockResponse(block *tmcoretypes.ResultBlock) crgtypes.BlockResponse
+	// BlockResponse returns a block response given a result block and its execution results
+	BlockResponse(block *tmcoretypes.ResultBlock, results *tmcoretypes.ResultBlockResults, params *tmcoretypes.ResultConsensusParams) crgtypes.BlockResponse
 	// BeginBlockToTx converts the given begin block hash to rosetta transaction hash
 	FinalizeBlockTxHash(blockHash []byte) string
 	// Amounts converts sdk.Coins to rosetta.Amounts
@@ -68,6 +72,9 @@ type ToRosettaConverter interface {
 	Meta(msg sdk.Msg) (meta map[string]interface{}, err error)
 	// SignerData returns account signing data from a queried any account
 	SignerData(anyAccount *codectypes.Any) (*SignerData, error)
+	// LockedCoins returns the coins of a queried any account locked by its vesting schedule
+	// at blockTime, vesting reports whether the account is a vesting account
+	LockedCoins(anyAccount *codectypes.Any, blockTime time.Time) (locked sdk.Coins, vesting bool, err error)
 	// SigningComponents returns rosetta's components required to build a signable transaction
 	SigningComponents(tx authsigning.Tx, metadata *ConstructionMetadata, rosPubKeys []*rosettatypes.PublicKey) (txBytes []byte, payloadsToSign []*rosettatypes.SigningPayload, err error)
 	// Tx converts a CometBFT transaction and tx result if provided to a rosetta tx
@@ -75,7 +82,7 @@ type ToRosettaConverter interface {
 	// TxIdentifiers converts a CometBFT tx to transaction identifiers
 	TxIdentifiers(txs []cmttypes.Tx) []*rosettatypes.TransactionIdentifier
 	// BalanceOps converts events to balance operations
-	BalanceOps(status string, events []abci.Event) []*rosettatypes.Operation
+	BalanceOps(status string, events []abci.Event) ([]*rosettatypes.Operation, error)
 	// SyncStatus converts a CometBFT status to sync status
 	SyncStatus(status *tmcoretypes.ResultStatus) *rosettatypes.SyncStatus
 	// Peers converts CometBFT peers to rosetta
@@ -109,9 +116,21 @@ type converter struct {
 	ir              codectypes.InterfaceRegistry
 	cdc             *codec.ProtoCodec
 	ac              address.Codec
+	// denoms fills the decimals and the metadata of the currencies
+	denoms *denomRegistry
+	// modules are the names of the module accounts keyed by address
+	modules map[string]string
+	// rawOperationTypes keeps the event types as the types of the balance operations
+	rawOperationTypes bool
 }
 
 func NewConverter(cdc *codec.ProtoCodec, ir codectypes.InterfaceRegistry, cfg sdkclient.TxConfig, ac address.Codec) Converter {
+	return newConverter(cdc, ir, cfg, ac, newDenomRegistry(nil, nil, nil), false)
+}
+
+// newConverter returns a converter filling the currencies from the metadata of denoms,
+// the balance operations keep the event types as their types if rawOperationTypes is set
+func newConverter(cdc *codec.ProtoCodec, ir codectypes.InterfaceRegistry, cfg sdkclient.TxConfig, ac address.Codec, denoms *denomRegistry, rawOperationTypes bool) converter {
 	return converter{
 		newTxBuilder:    cfg.NewTxBuilder,
 		txBuilderFromTx: cfg.WrapTxBuilder,
@@ -131,9 +150,12 @@ func NewConverter(cdc *codec.ProtoCodec, ir codectypes.InterfaceRegistry, cfg sd
 
 			return crypto.Sha256(bytesToSign), nil
 		},
-		ir:  ir,
-		cdc: cdc,
-		ac:  ac,
+		ir:                ir,
+		cdc:               cdc,
+		ac:                ac,
+		denoms:            denoms,
+		modules:           moduleAccounts(ac),
+		rawOperationTypes: rawOperationTypes,
 	}
 }
 
@@ -295,50 +317,238 @@ func (c converter) Tx(rawTx cmttypes.Tx, txResult *abci.ExecTxResult) (*rosettat
 	msgs := tx.GetMsgs()
 
 	var rawTxOps []*rosettatypes.Operation
+	// msgOpIndexes are the indexes of the operations of each message
+	msgOpIndexes := make([][]int64, len(msgs))
 
-	for _, msg := range msgs {
+	for i, msg := range msgs {
 		ops, err := c.Ops(status, msg)
 		if err != nil {
 			return nil, crgerrs.WrapError(crgerrs.ErrConverter, fmt.Sprintf("while getting operations from status and msg %s", err.Error()))
 		}
+		for j := range ops {
+			msgOpIndexes[i] = append(msgOpIndexes[i], int64(len(rawTxOps)+j))
+		}
 		rawTxOps = append(rawTxOps, ops...)
 	}
 
 	// now get balance events from response deliver tx
-	var balanceOps []*rosettatypes.Operation
+	var (
+		balanceOps []*rosettatypes.Operation
+		msgIndexes []int
+	)
 	// tx result might be nil, in case we're querying an unconfirmed tx from the mempool
 	if txResult != nil {
-		balanceOps = c.BalanceOps(StatusTxSuccess, txResult.Events) // force set to success because no events for failed tx
+		balanceOps, msgIndexes, err = c.balanceOps(StatusTxSuccess, txResult.Events) // force set to success because no events for failed tx
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// now normalize indexes
 	totalOps := AddOperationIndexes(rawTxOps, balanceOps)
 
+	// the balance operations are related to the operations of the message which caused them
+	for i, op := range balanceOps {
+		if msgIndexes[i] < 0 || msgIndexes[i] >= len(msgOpIndexes) {
+			continue
+		}
+		related := make([]*rosettatypes.OperationIdentifier, 0, len(msgOpIndexes[msgIndexes[i]])+len(op.RelatedOperations))
+		for _, index := range msgOpIndexes[msgIndexes[i]] {
+			related = append(related, &rosettatypes.OperationIdentifier{Index: index})
+		}
+		op.RelatedOperations = append(related, op.RelatedOperations...)
+	}
+
+	meta, err := c.txMetadata(tx, txResult)
+	if err != nil {
+		return nil, err
+	}
+
 	return &rosettatypes.Transaction{
 		TransactionIdentifier: &rosettatypes.TransactionIdentifier{Hash: fmt.Sprintf("%X", rawTx.Hash())},
 		Operations:            totalOps,
+		Metadata:              meta,
 	}, nil
 }
 
-func (c converter) BalanceOps(status string, events []abci.Event) []*rosettatypes.Operation {
-	var ops []*rosettatypes.Operation
+// txMetadata returns the gas, fee, memo and messages of the transaction, and its execution result if not nil
+func (c converter) txMetadata(tx sdk.Tx, txResult *abci.ExecTxResult) (map[string]interface{}, error) {
+	meta := TxMetadata{MessageTypes: make([]string, len(tx.GetMsgs()))}
+	for i, msg := range tx.GetMsgs() {
+		meta.MessageTypes[i] = sdk.MsgTypeURL(msg)
+	}
+	if feeTx, ok := tx.(sdk.FeeTx); ok {
+		meta.GasWanted = feeTx.GetGas()
+		meta.Fee = feeTx.GetFee()
+		if payer := feeTx.FeePayer(); len(payer) != 0 {
+			feePayer, err := c.ac.BytesToString(payer)
+			if err != nil {
+				return nil, crgerrs.WrapError(crgerrs.ErrCodec, fmt.Sprintf("encoding fee payer %s", err.Error()))
+			}
+			meta.FeePayer = feePayer
+		}
+		if granter := feeTx.FeeGranter(); len(granter) != 0 {
+			feeGranter, err := c.ac.BytesToString(granter)
+			if err != nil {
+				return nil, crgerrs.WrapError(crgerrs.ErrCodec, fmt.Sprintf("encoding fee granter %s", err.Error()))
+			}
+			meta.FeeGranter = feeGranter
+		}
+	}
+	if memoTx, ok := tx.(sdk.TxWithMemo); ok {
+		meta.Memo = memoTx.GetMemo()
+	}
+	if timeoutTx, ok := tx.(sdk.TxWithTimeoutHeight); ok {
+		meta.TimeoutHeight = timeoutTx.GetTimeoutHeight()
+	}
+	if txResult != nil {
+		meta.GasUsed = &txResult.GasUsed
+		meta.Code = &txResult.Code
+		meta.Codespace = txResult.Codespace
+		meta.Log = txResult.Log
+	}
+	return meta.ToMetadata()
+}
 
+func (c converter) BalanceOps(status string, events []abci.Event) ([]*rosettatypes.Operation, error) {
+	ops, _, err := c.balanceOps(status, events)
+	return ops, err
+}
+
+// balanceOps converts events to balance operations, it also returns for each operation the index
+// of the message which emitted its event, -1 if it was not emitted by a message. The operations
+// are indexed from 0, and the credit of a transfer, a coin_received event following a coin_spent
+// event of the same message and amount, lists its debit in its related operations. The debit does
+// not list its credit back, as operations may only relate to lower indexes. Unless raw operation
+// types are kept, the transfer paying the fee of the tx event following it, emitted by the ante
+// handler, is made of fee operations, and the credits and the transfers are classified from the
+// module accounts involved and the events emitted along with them.
+func (c converter) balanceOps(status string, events []abci.Event) (ops []*rosettatypes.Operation, msgIndexes []int, err error) {
+	var scopes map[int]map[string]bool
+	if !c.rawOperationTypes {
+		scopes = eventScopes(events)
+	}
+	var (
+		// spentOps are the operations of the previous event if it was a coin_spent one
+		spentOps      []*rosettatypes.Operation
+		spentMsgIndex int
+		// transferDebits and transferCredits are the operations of the last transfer
+		// not emitted by a message, until the next balance event
+		transferDebits, transferCredits []*rosettatypes.Operation
+	)
 	for _, e := range events {
-		balanceOps, ok := sdkEventToBalanceOperations(status, e)
+		balanceOps, ok, err := c.sdkEventToBalanceOperations(status, e)
+		if err != nil {
+			return nil, nil, err
+		}
 		if !ok {
+			spentOps = nil
+			if e.Type == sdk.EventTypeTx && !c.rawOperationTypes {
+				if err := c.feeOps(e, transferDebits, transferCredits); err != nil {
+					return nil, nil, err
+				}
+			}
 			continue
 		}
-		ops = append(ops, balanceOps...)
+		msgIndex, err := eventMsgIndex(e)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		transferDebits, transferCredits = nil, nil
+		// debits are the operations of the transfer debit if the event is its credit
+		var debits []*rosettatypes.Operation
+		if e.Type == banktypes.EventTypeCoinReceived && msgIndex == spentMsgIndex && isTransfer(spentOps, balanceOps) {
+			debits = spentOps
+			for i, op := range balanceOps {
+				op.RelatedOperations = []*rosettatypes.OperationIdentifier{{Index: spentOps[i].OperationIdentifier.Index}}
+			}
+			if msgIndex < 0 {
+				transferDebits, transferCredits = spentOps, balanceOps
+			}
+		}
+		if e.Type == banktypes.EventTypeCoinReceived && !c.rawOperationTypes && len(balanceOps) != 0 {
+			if opType := c.operationType(debits, balanceOps, scopes[msgIndex]); opType != "" {
+				for _, op := range slices.Concat(debits, balanceOps) {
+					op.Type = opType
+				}
+			}
+		}
+		spentOps = nil
+		if e.Type == banktypes.EventTypeCoinSpent {
+			spentOps, spentMsgIndex = balanceOps, msgIndex
+		}
+
+		for _, op := range balanceOps {
+			op.OperationIdentifier = &rosettatypes.OperationIdentifier{Index: int64(len(ops))}
+			ops = append(ops, op)
+			msgIndexes = append(msgIndexes, msgIndex)
+		}
 	}
 
-	return ops
+	return ops, msgIndexes, nil
 }
 
-// sdkEventToBalanceOperations converts an event to a rosetta balance operation
-// it will panic if the event is malformed because it might mean the sdk spec
-// has changed and rosetta needs to reflect those changes too.
+// feeOps sets the type of the operations of a transfer to fee if the transfer pays
+// the fee of the tx event, the tx events without fee are ignored
+func (c converter) feeOps(event abci.Event, debits, credits []*rosettatypes.Operation) error {
+	if _, ok := eventAttribute(event, sdk.AttributeKeyFeePayer); !ok || len(debits) == 0 {
+		return nil
+	}
+	payer, err := c.eventAddress(event, sdk.AttributeKeyFeePayer)
+	if err != nil {
+		return err
+	}
+	fee, err := eventCoins(event, sdk.AttributeKeyFee)
+	if err != nil {
+		return err
+	}
+
+	if debits[0].Account.Address != payer || len(debits) != len(fee) {
+		return nil
+	}
+	for i, coin := range fee {
+		if debits[i].Amount.Currency.Symbol != coin.Denom || debits[i].Amount.Value != "-"+coin.Amount.String() {
+			return nil
+		}
+	}
+	for _, op := range slices.Concat(debits, credits) {
+		op.Type = OperationFee
+	}
+	return nil
+}
+
+// isTransfer returns true if the credits move the amounts of the debits
+func isTransfer(debits, credits []*rosettatypes.Operation) bool {
+	if len(debits) == 0 || len(debits) != len(credits) {
+		return false
+	}
+	for i := range debits {
+		if debits[i].Amount.Currency.Symbol != credits[i].Amount.Currency.Symbol || debits[i].Amount.Value != "-"+credits[i].Amount.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// attributeKeyMsgIndex is the attribute holding the index of the message which emitted an event,
+// it is added by the sdk since v0.50
+const attributeKeyMsgIndex = "msg_index"
+
+// eventMsgIndex returns the index of the message which emitted the event, -1 if the
+// event was not emitted by a message such as the fee payment and block events
+func eventMsgIndex(event abci.Event) (int, error) {
+	if _, ok := eventAttribute(event, attributeKeyMsgIndex); !ok {
+		return -1, nil
+	}
+	return parseEventAttribute(event, attributeKeyMsgIndex, strconv.Atoi)
+}
+
+// sdkEventToBalanceOperations converts an event to a rosetta balance operation, it returns
+// an error if the event is malformed because it might mean the sdk spec has changed and
+// rosetta needs to reflect those changes too.
 // The balance operations are multiple, one for each denom.
-func sdkEventToBalanceOperations(status string, event abci.Event) (operations []*rosettatypes.Operation, isBalanceEvent bool) {
+func (c converter) sdkEventToBalanceOperations(status string, event abci.Event) (operations []*rosettatypes.Operation, isBalanceEvent bool, err error) {
 	var (
 		accountIdentifier string
 		coinChange        sdk.Coins
@@ -347,45 +557,32 @@ func sdkEventToBalanceOperations(status string, event abci.Event) (operations []
 
 	switch event.Type {
 	default:
-		return nil, false
+		return nil, false, nil
 	case banktypes.EventTypeCoinSpent:
-		spender := sdk.MustAccAddressFromBech32(event.Attributes[0].Value)
-		coins, err := sdk.ParseCoinsNormalized(event.Attributes[1].Value)
+		accountIdentifier, err = c.eventAddress(event, banktypes.AttributeKeySpender)
 		if err != nil {
-			panic(err)
+			return nil, true, err
 		}
-
 		isSub = true
-		coinChange = coins
-		accountIdentifier = spender.String()
 
 	case banktypes.EventTypeCoinReceived:
-		receiver := sdk.MustAccAddressFromBech32(event.Attributes[0].Value)
-		coins, err := sdk.ParseCoinsNormalized(event.Attributes[1].Value)
+		accountIdentifier, err = c.eventAddress(event, banktypes.AttributeKeyReceiver)
 		if err != nil {
-			panic(err)
+			return nil, true, err
 		}
-
 		isSub = false
-		coinChange = coins
-		accountIdentifier = receiver.String()
 
 	// rosetta does not have the concept of burning coins, so we need to mock
 	// the burn as a send to an address that cannot be resolved to anything
 	case banktypes.EventTypeCoinBurn:
-		coin, err := base64.StdEncoding.DecodeString(event.Attributes[1].Value)
-		if err != nil {
-			panic(err)
-		}
-		coins, err := sdk.ParseCoinsNormalized(string(coin))
-		if err != nil {
-			panic(err)
-		}
-
-		coinChange = coins
 		accountIdentifier = BurnerAddressIdentifier
 	}
 
+	coinChange, err = eventCoins(event, sdk.AttributeKeyAmount)
+	if err != nil {
+		return nil, true, err
+	}
+
 	operations = make([]*rosettatypes.Operation, len(coinChange))
 
 	for i, coin := range coinChange {
@@ -402,17 +599,63 @@ func sdkEventToBalanceOperations(status string, event abci.Event) (operations []
 			Status:  &status,
 			Account: &rosettatypes.AccountIdentifier{Address: accountIdentifier},
 			Amount: &rosettatypes.Amount{
-				Value: value,
-				Currency: &rosettatypes.Currency{
-					Symbol:   coin.Denom,
-					Decimals: 0,
-				},
+				Value:    value,
+				Currency: c.denoms.currency(coin.Denom),
 			},
 		}
 
 		operations[i] = op
 	}
-	return operations, true
+	return operations, true, nil
+}
+
+// eventAttribute returns the value of the attribute of the event with the given key, the
+// attributes emitted by CometBFT versions before v0.37 are base64 encoded
+func eventAttribute(event abci.Event, key string) (string, bool) {
+	encodedKey := base64.StdEncoding.EncodeToString([]byte(key))
+	for _, attr := range event.Attributes {
+		if attr.Key == key || attr.Key == encodedKey {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// parseEventAttribute parses the value of the attribute of the event with the given key,
+// the plain value is parsed first then its base64 decoding, if any
+func parseEventAttribute[T any](event abci.Event, key string, parse func(value string) (T, error)) (T, error) {
+	value, ok := eventAttribute(event, key)
+	if !ok {
+		var zero T
+		return zero, crgerrs.WrapError(crgerrs.ErrInterpreting, fmt.Sprintf("%s event without %s attribute", event.Type, key))
+	}
+	parsed, err := parse(value)
+	if err == nil {
+		return parsed, nil
+	}
+	if decoded, decodeErr := base64.StdEncoding.DecodeString(value); decodeErr == nil {
+		if parsed, decodedErr := parse(string(decoded)); decodedErr == nil {
+			return parsed, nil
+		}
+	}
+	return parsed, crgerrs.WrapError(crgerrs.ErrInterpreting, fmt.Sprintf("invalid %s attribute of %s event %s", key, event.Type, err.Error()))
+}
+
+// eventAddress returns the address in the attribute of the event with the given key,
+// it must have the bech32 prefix of the network
+func (c converter) eventAddress(event abci.Event, key string) (string, error) {
+	return parseEventAttribute(event, key, func(value string) (string, error) {
+		bz, err := c.ac.StringToBytes(value)
+		if err != nil {
+			return "", err
+		}
+		return c.ac.BytesToString(bz)
+	})
+}
+
+// eventCoins returns the coins in the attribute of the event with the given key
+func eventCoins(event abci.Event, key string) (sdk.Coins, error) {
+	return parseEventAttribute(event, key, sdk.ParseCoinsNormalized)
 }
 
 // Amounts converts []sdk.Coin to rosetta amounts
@@ -428,18 +671,14 @@ func (c converter) Amounts(ownedCoins []sdk.Coin, availableCoins sdk.Coins) []*r
 		value, owned := ownedCoinsMap[coin.Denom]
 		if !owned {
 			amounts[i] = &rosettatypes.Amount{
-				Value: sdkmath.NewInt(0).String(),
-				Currency: &rosettatypes.Currency{
-					Symbol: coin.Denom,
-				},
+				Value:    sdkmath.NewInt(0).String(),
+				Currency: c.denoms.currency(coin.Denom),
 			}
 			continue
 		}
 		amounts[i] = &rosettatypes.Amount{
-			Value: value.String(),
-			Currency: &rosettatypes.Currency{
-				Symbol: coin.Denom,
-			},
+			Value:    value.String(),
+			Currency: c.denoms.currency(coin.Denom),
 		}
 	}
 
@@ -447,7 +686,8 @@ func (c converter) Amounts(ownedCoins []sdk.Coin, availableCoins sdk.Coins) []*r
 }
 
 // AddOperationIndexes adds the indexes to operations adhering to specific rules:
-// operations related to messages will be always before than the balance ones
+// operations related to messages will be always before than the balance ones.
+// The related operations of the balance ops are shifted along with them.
 func AddOperationIndexes(msgOps, balanceOps []*rosettatypes.Operation) (finalOps []*rosettatypes.Operation) {
 	lenMsgOps := len(msgOps)
 	lenBalanceOps := len(balanceOps)
@@ -466,6 +706,9 @@ func AddOperationIndexes(msgOps, balanceOps []*rosettatypes.Operation) (finalOps
 
 	// add indexes to balance ops
 	for _, op := range balanceOps {
+		for _, related := range op.RelatedOperations {
+			related.Index += int64(lenMsgOps)
+		}
 		op.OperationIdentifier = &rosettatypes.OperationIdentifier{
 			Index: currentIndex,
 		}
@@ -530,8 +773,10 @@ func (c converter) TxIdentifiers(txs []cmttypes.Tx) []*rosettatypes.TransactionI
 	return converted
 }
 
-// tmResultBlockToRosettaBlockResponse converts a CometBFT result block to block response
-func (c converter) BlockResponse(block *tmcoretypes.ResultBlock) crgtypes.BlockResponse {
+// BlockResponse converts a CometBFT result block to block response, the block results and the consensus
+// params at the block height are nil when only the block identifier is needed, the block metadata then
+// lacks the gas used and the consensus params version
+func (c converter) BlockResponse(block *tmcoretypes.ResultBlock, results *tmcoretypes.ResultBlockResults, params *tmcoretypes.ResultConsensusParams) crgtypes.BlockResponse {
 	var parentBlock *rosettatypes.BlockIdentifier
 
 	switch block.Block.Height {
@@ -554,7 +799,51 @@ func (c converter) BlockResponse(block *tmcoretypes.ResultBlock) crgtypes.BlockR
 		ParentBlock:          parentBlock,
 		MillisecondTimestamp: timeToMilliseconds(block.Block.Time),
 		TxCount:              int64(len(block.Block.Txs)),
+		Metadata:             blockMetadata(block, results, params),
+	}
+}
+
+// blockMetadata returns the header and last commit information of the block, the gas used by its
+// transactions if results is set, and the app version of the consensus params if params is set.
+// The header app version is the app protocol version set in the header of the block.
+func blockMetadata(block *tmcoretypes.ResultBlock, results *tmcoretypes.ResultBlockResults, params *tmcoretypes.ResultConsensusParams) map[string]interface{} {
+	lastCommit := map[string]interface{}{}
+	if commit := block.Block.LastCommit; commit != nil {
+		signatures := 0
+		for _, sig := range commit.Signatures {
+			if sig.BlockIDFlag == cmttypes.BlockIDFlagCommit {
+				signatures++
+			}
+		}
+		lastCommit = map[string]interface{}{
+			"height":     commit.Height,
+			"round":      commit.Round,
+			"block_hash": commit.BlockID.Hash.String(),
+			"signatures": signatures,
+			"validators": len(commit.Signatures),
+		}
+	}
+
+	metadata := map[string]interface{}{
+		"chain_id":           block.Block.ChainID,
+		"proposer_address":   block.Block.ProposerAddress.String(),
+		"app_hash":           block.Block.AppHash.String(),
+		"header_app_version": block.Block.Version.App,
+		"block_version":      block.Block.Version.Block,
+		"tx_count":           len(block.Block.Txs),
+		"last_commit":        lastCommit,
+	}
+	if results != nil {
+		var gasUsed int64
+		for _, txResult := range results.TxResults {
+			gasUsed += txResult.GasUsed
+		}
+		metadata["gas_used"] = gasUsed
 	}
+	if params != nil {
+		metadata["consensus_app_version"] = params.ConsensusParams.Version.App
+	}
+	return metadata
 }
 
 // Peers converts tm peers to rosetta peers
@@ -773,10 +1062,9 @@ func (c converter) SigningComponents(tx authsigning.Tx, metadata *ConstructionMe
 
 // SignerData converts the given any account to signer data
 func (c converter) SignerData(anyAccount *codectypes.Any) (*SignerData, error) {
-	var acc sdkclient.Account
-	err := c.ir.UnpackAny(anyAccount, &acc)
+	acc, err := c.account(anyAccount)
 	if err != nil {
-		return nil, crgerrs.WrapError(crgerrs.ErrConverter, fmt.Sprintf("while unpacking an account %s", err.Error()))
+		return nil, err
 	}
 
 	return &SignerData{
@@ -784,3 +1072,26 @@ func (c converter) SignerData(anyAccount *codectypes.Any) (*SignerData, error) {
 		Sequence:      acc.GetSequence(),
 	}, nil
 }
+
+func (c converter) LockedCoins(anyAccount *codectypes.Any, blockTime time.Time) (sdk.Coins, bool, error) {
+	acc, err := c.account(anyAccount)
+	if err != nil {
+		return nil, false, err
+	}
+
+	vestingAcc, ok := acc.(vestingexported.VestingAccount)
+	if !ok {
+		return sdk.Coins{}, false, nil
+	}
+	return vestingAcc.LockedCoins(blockTime), true, nil
+}
+
+// account unpacks a queried any account, vesting accounts are unpacked to their concrete type
+func (c converter) account(anyAccount *codectypes.Any) (sdk.AccountI, error) {
+	var acc sdk.AccountI
+	err := c.ir.UnpackAny(anyAccount, &acc)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConverter, fmt.Sprintf("while unpacking an account %s", err.Error()))
+	}
+	return acc, nil
+}
@@ -1,12 +1,18 @@
 package rosetta_test
 
 import (
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"slices"
 	"testing"
+	"time"
 
 	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
 	abci "github.com/cometbft/cometbft/abci/types"
+	cmtversion "github.com/cometbft/cometbft/api/cometbft/version/v1"
+	tmcoretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
 	"github.com/stretchr/testify/suite"
 
 	bank "cosmossdk.io/x/bank/types"
@@ -18,6 +24,8 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	authsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
 	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingtypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
 
 	"github.com/cosmos/rosetta"
 	crgerrs "github.com/cosmos/rosetta/lib/errors"
@@ -284,7 +292,8 @@ func (s *ConverterTestSuite) TestBalanceOps() {
 			Type: "not-a-balance-op",
 		}
 
-		ops := s.c.ToRosetta().BalanceOps("", []abci.Event{notBalanceOp})
+		ops, err := s.c.ToRosetta().BalanceOps("", []abci.Event{notBalanceOp})
+		s.Require().NoError(err)
 		s.Len(ops, 0, "expected no balance ops")
 	})
 
@@ -302,32 +311,299 @@ func (s *ConverterTestSuite) TestBalanceOps() {
 			sdk.NewAttribute(sdk.AttributeKeyAmount, sdk.NewCoins(sdk.NewInt64Coin("test", 10), sdk.NewInt64Coin("utxo", 10)).String()),
 		)
 
-		ops := s.c.ToRosetta().BalanceOps("", []abci.Event{(abci.Event)(subBalanceOp), (abci.Event)(addBalanceOp)})
+		ops, err := s.c.ToRosetta().BalanceOps("", []abci.Event{(abci.Event)(subBalanceOp), (abci.Event)(addBalanceOp)})
+		s.Require().NoError(err)
 		s.Len(ops, 4)
 	})
 
+	s.Run("network bech32 prefix", func() {
+		ac := address.NewBech32Codec("osmo")
+		receiver, err := ac.BytesToString(sdk.AccAddress("test"))
+		s.Require().NoError(err)
+		addBalanceOp := sdk.NewEvent(
+			bank.EventTypeCoinReceived,
+			sdk.NewAttribute(bank.AttributeKeyReceiver, receiver),
+			sdk.NewAttribute(sdk.AttributeKeyAmount, "10uosmo"),
+		)
+
+		ops, err := rosetta.NewConverter(s.cdc, s.ir, s.txConf, ac).ToRosetta().BalanceOps("", []abci.Event{(abci.Event)(addBalanceOp)})
+		s.Require().NoError(err)
+		s.Require().Len(ops, 1)
+		s.Require().Equal(receiver, ops[0].Account.Address)
+	})
+
+	s.Run("attributes by key, plain or base64 encoded", func() {
+		encode := func(v string) string { return base64.StdEncoding.EncodeToString([]byte(v)) }
+		addr := sdk.AccAddress("test").String()
+		events := []abci.Event{
+			{Type: bank.EventTypeCoinReceived, Attributes: []abci.EventAttribute{
+				{Key: sdk.AttributeKeyAmount, Value: "10stake"},
+				{Key: "msg_index", Value: "0"},
+				{Key: bank.AttributeKeyReceiver, Value: addr},
+			}},
+			{Type: bank.EventTypeCoinSpent, Attributes: []abci.EventAttribute{
+				{Key: encode(bank.AttributeKeySpender), Value: encode(addr)},
+				{Key: encode(sdk.AttributeKeyAmount), Value: encode("10stake")},
+			}},
+			{Type: bank.EventTypeCoinBurn, Attributes: []abci.EventAttribute{
+				{Key: bank.AttributeKeyBurner, Value: addr},
+				{Key: sdk.AttributeKeyAmount, Value: "5stake"},
+			}},
+			{Type: bank.EventTypeCoinBurn, Attributes: []abci.EventAttribute{
+				{Key: bank.AttributeKeyBurner, Value: addr},
+				{Key: sdk.AttributeKeyAmount, Value: encode("5stake")},
+			}},
+		}
+		ops, err := s.c.ToRosetta().BalanceOps("", events)
+		s.Require().NoError(err)
+		s.Require().Len(ops, 4)
+		s.Require().Equal(addr, ops[0].Account.Address)
+		s.Require().Equal("10", ops[0].Amount.Value)
+		s.Require().Equal(addr, ops[1].Account.Address)
+		s.Require().Equal("-10", ops[1].Amount.Value)
+		s.Require().Equal(rosetta.BurnerAddressIdentifier, ops[2].Account.Address)
+		s.Require().Equal("5", ops[3].Amount.Value)
+	})
+
 	s.Run("spec broken", func() {
-		s.Require().Panics(func() {
-			specBrokenSub := abci.Event{
-				Type: bank.EventTypeCoinSpent,
-			}
-			_ = s.c.ToRosetta().BalanceOps("", []abci.Event{specBrokenSub})
-		})
+		addr := sdk.AccAddress("test").String()
+		for _, event := range []abci.Event{
+			{Type: bank.EventTypeCoinSpent},
+			{Type: bank.EventTypeCoinBurn},
+			{Type: bank.EventTypeCoinReceived},
+			{Type: bank.EventTypeCoinReceived, Attributes: []abci.EventAttribute{
+				{Key: bank.AttributeKeyReceiver, Value: addr},
+				{Key: sdk.AttributeKeyAmount, Value: "not coins"},
+			}},
+			// the address must have the bech32 prefix of the network
+			{Type: bank.EventTypeCoinSpent, Attributes: []abci.EventAttribute{
+				{Key: bank.AttributeKeySpender, Value: sdk.MustBech32ifyAddressBytes("osmo", sdk.AccAddress("test"))},
+				{Key: sdk.AttributeKeyAmount, Value: "10stake"},
+			}},
+		} {
+			_, err := s.c.ToRosetta().BalanceOps("", []abci.Event{event})
+			s.Require().ErrorIs(err, crgerrs.ErrInterpreting)
+		}
+	})
+}
+
+func (s *ConverterTestSuite) TestLockedCoins() {
+	// the cached value is dropped as the accounts queried from the node are decoded from bytes only
+	toAny := func(acc sdk.AccountI) *codectypes.Any {
+		anyAcc, err := codectypes.NewAnyWithValue(acc)
+		s.Require().NoError(err)
+		return &codectypes.Any{TypeUrl: anyAcc.TypeUrl, Value: anyAcc.Value}
+	}
+	baseAcc := authtypes.NewBaseAccount(sdk.AccAddress("test"), nil, 3, 7)
+
+	s.Run("base account", func() {
+		signerData, err := s.c.ToRosetta().SignerData(toAny(baseAcc))
+		s.Require().NoError(err)
+		s.Require().Equal(&rosetta.SignerData{AccountNumber: 3, Sequence: 7}, signerData)
+
+		locked, vesting, err := s.c.ToRosetta().LockedCoins(toAny(baseAcc), time.Unix(0, 0))
+		s.Require().NoError(err)
+		s.Require().False(vesting)
+		s.Require().Empty(locked)
+	})
+
+	s.Run("continuous vesting account", func() {
+		vestingAcc, err := vestingtypes.NewContinuousVestingAccount(baseAcc, sdk.NewCoins(sdk.NewInt64Coin("stake", 100)), 1000, 2000)
+		s.Require().NoError(err)
+
+		signerData, err := s.c.ToRosetta().SignerData(toAny(vestingAcc))
+		s.Require().NoError(err)
+		s.Require().Equal(&rosetta.SignerData{AccountNumber: 3, Sequence: 7}, signerData)
 
-		s.Require().Panics(func() {
-			specBrokenSub := abci.Event{
-				Type: bank.EventTypeCoinBurn,
-			}
-			_ = s.c.ToRosetta().BalanceOps("", []abci.Event{specBrokenSub})
+		locked, vesting, err := s.c.ToRosetta().LockedCoins(toAny(vestingAcc), time.Unix(1500, 0))
+		s.Require().NoError(err)
+		s.Require().True(vesting)
+		s.Require().Equal(sdk.NewCoins(sdk.NewInt64Coin("stake", 50)), locked)
+
+		locked, _, err = s.c.ToRosetta().LockedCoins(toAny(vestingAcc), time.Unix(2000, 0))
+		s.Require().NoError(err)
+		s.Require().Empty(locked)
+	})
+}
+
+func (s *ConverterTestSuite) TestTxMetadata() {
+	s.Run("delivered tx", func() {
+		tx, err := s.c.ToRosetta().Tx(s.unsignedTxBytes, &abci.ExecTxResult{
+			Code:      5,
+			Codespace: "sdk",
+			Log:       "insufficient funds",
+			GasWanted: 250000,
+			GasUsed:   1000,
 		})
+		s.Require().NoError(err)
+		s.Require().Equal(map[string]interface{}{
+			"gas_wanted":    float64(250000),
+			"gas_used":      float64(1000),
+			"fee":           []interface{}{map[string]interface{}{"denom": "stake", "amount": "1"}},
+			"fee_payer":     "cosmos147klh7th5jkjy3aajsj2rqvhtvh9mfde37wq5g",
+			"code":          float64(5),
+			"codespace":     "sdk",
+			"log":           "insufficient funds",
+			"message_types": []interface{}{"/cosmos.bank.v1beta1.MsgSend"},
+		}, tx.Metadata)
+	})
+
+	s.Run("unconfirmed tx", func() {
+		tx, err := s.c.ToRosetta().Tx(s.unsignedTxBytes, nil)
+		s.Require().NoError(err)
+		s.Require().NotContains(tx.Metadata, "gas_used")
+		s.Require().NotContains(tx.Metadata, "code")
+		s.Require().Equal(float64(250000), tx.Metadata["gas_wanted"])
+	})
+}
+
+func (s *ConverterTestSuite) TestTxRelatedOperations() {
+	from, to := "cosmos147klh7th5jkjy3aajsj2rqvhtvh9mfde37wq5g", "cosmos1mnvp8lxkafy4lxwwaqu5eae7dxv0dz6hwgyt63"
+	feeCollector := authtypes.NewModuleAddress(authtypes.FeeCollectorName).String()
+	balanceEvent := func(eventType, key, addr, amount string, msgIndex ...string) abci.Event {
+		event := sdk.NewEvent(eventType, sdk.NewAttribute(key, addr), sdk.NewAttribute(sdk.AttributeKeyAmount, amount))
+		for _, index := range msgIndex {
+			event = event.AppendAttributes(sdk.NewAttribute("msg_index", index))
+		}
+		return abci.Event(event)
+	}
+
+	tx, err := s.c.ToRosetta().Tx(s.unsignedTxBytes, &abci.ExecTxResult{Events: []abci.Event{
+		// the fee is paid before the messages are executed
+		balanceEvent(bank.EventTypeCoinSpent, bank.AttributeKeySpender, from, "1stake"),
+		balanceEvent(bank.EventTypeCoinReceived, bank.AttributeKeyReceiver, feeCollector, "1stake"),
+		{Type: bank.EventTypeTransfer},
+		balanceEvent(bank.EventTypeCoinSpent, bank.AttributeKeySpender, from, "16stake", "0"),
+		balanceEvent(bank.EventTypeCoinReceived, bank.AttributeKeyReceiver, to, "16stake", "0"),
+		balanceEvent(bank.EventTypeCoinReceived, bank.AttributeKeyReceiver, to, "5stake", "0"),
+	}})
+	s.Require().NoError(err)
+
+	related := make([][]int64, len(tx.Operations))
+	for i, op := range tx.Operations {
+		s.Require().Equal(int64(i), op.OperationIdentifier.Index)
+		for _, relatedOp := range op.RelatedOperations {
+			related[i] = append(related[i], relatedOp.Index)
+		}
+	}
+	s.Require().Equal([][]int64{
+		nil,    // MsgSend
+		nil,    // fee debit, not related to its credit
+		{1},    // fee credit
+		{0},    // MsgSend debit, not related to its credit
+		{0, 3}, // MsgSend credit
+		{0},    // credit without debit
+	}, related)
+}
+
+func (s *ConverterTestSuite) TestTxFeeOperations() {
+	payer := "cosmos147klh7th5jkjy3aajsj2rqvhtvh9mfde37wq5g"
+	feeCollector := authtypes.NewModuleAddress(authtypes.FeeCollectorName).String()
+	feeEvents := []abci.Event{
+		abci.Event(sdk.NewEvent(bank.EventTypeCoinSpent, sdk.NewAttribute(bank.AttributeKeySpender, payer), sdk.NewAttribute(sdk.AttributeKeyAmount, "1stake"))),
+		abci.Event(sdk.NewEvent(bank.EventTypeCoinReceived, sdk.NewAttribute(bank.AttributeKeyReceiver, feeCollector), sdk.NewAttribute(sdk.AttributeKeyAmount, "1stake"))),
+		abci.Event(sdk.NewEvent(bank.EventTypeTransfer)),
+		abci.Event(sdk.NewEvent(sdk.EventTypeTx, sdk.NewAttribute(sdk.AttributeKeyFee, "1stake"), sdk.NewAttribute(sdk.AttributeKeyFeePayer, payer))),
+	}
+	types := func(tx *rosettatypes.Transaction) []string {
+		types := make([]string, len(tx.Operations))
+		for i, op := range tx.Operations {
+			types[i] = op.Type
+		}
+		return types
+	}
+
+	s.Run("reverted tx", func() {
+		// a reverted tx only has the events of the ante handler, which pays the fee
+		tx, err := s.c.ToRosetta().Tx(s.unsignedTxBytes, &abci.ExecTxResult{Code: 5, Events: feeEvents})
+		s.Require().NoError(err)
+		s.Require().Equal([]string{"/cosmos.bank.v1beta1.MsgSend", rosetta.OperationFee, rosetta.OperationFee}, types(tx))
+		s.Require().Equal(rosetta.StatusTxReverted, *tx.Operations[0].Status)
+		s.Require().Equal(rosetta.StatusTxSuccess, *tx.Operations[1].Status)
+		s.Require().Equal(payer, tx.Operations[1].Account.Address)
+		s.Require().Equal("-1", tx.Operations[1].Amount.Value)
+		s.Require().Equal(feeCollector, tx.Operations[2].Account.Address)
+		s.Require().Equal("1", tx.Operations[2].Amount.Value)
+	})
 
-		s.Require().Panics(func() {
-			specBrokenSub := abci.Event{
-				Type: bank.EventTypeCoinReceived,
-			}
-			_ = s.c.ToRosetta().BalanceOps("", []abci.Event{specBrokenSub})
+	s.Run("successful tx", func() {
+		events := slices.Concat(feeEvents, []abci.Event{
+			abci.Event(sdk.NewEvent(bank.EventTypeCoinSpent, sdk.NewAttribute(bank.AttributeKeySpender, payer), sdk.NewAttribute(sdk.AttributeKeyAmount, "1stake"), sdk.NewAttribute("msg_index", "0"))),
+			abci.Event(sdk.NewEvent(bank.EventTypeCoinReceived, sdk.NewAttribute(bank.AttributeKeyReceiver, feeCollector), sdk.NewAttribute(sdk.AttributeKeyAmount, "1stake"), sdk.NewAttribute("msg_index", "0"))),
 		})
+		tx, err := s.c.ToRosetta().Tx(s.unsignedTxBytes, &abci.ExecTxResult{Events: events})
+		s.Require().NoError(err)
+		s.Require().Equal([]string{
+			"/cosmos.bank.v1beta1.MsgSend",
+			rosetta.OperationFee,
+			rosetta.OperationFee,
+			bank.EventTypeCoinSpent,
+			bank.EventTypeCoinReceived,
+		}, types(tx))
 	})
+
+	s.Run("transfer not paying the fee", func() {
+		tx, err := s.c.ToRosetta().Tx(s.unsignedTxBytes, &abci.ExecTxResult{Events: []abci.Event{
+			feeEvents[0], feeEvents[1], feeEvents[2],
+			abci.Event(sdk.NewEvent(sdk.EventTypeTx, sdk.NewAttribute(sdk.AttributeKeyFee, "2stake"), sdk.NewAttribute(sdk.AttributeKeyFeePayer, payer))),
+		}})
+		s.Require().NoError(err)
+		s.Require().Equal([]string{"/cosmos.bank.v1beta1.MsgSend", bank.EventTypeCoinSpent, bank.EventTypeCoinReceived}, types(tx))
+	})
+}
+
+func (s *ConverterTestSuite) TestBlockResponse() {
+	block := &tmcoretypes.ResultBlock{Block: &cmttypes.Block{
+		Header: cmttypes.Header{
+			Version:         cmtversion.Consensus{Block: 11, App: 2},
+			ChainID:         "cosmoshub-4",
+			Height:          10,
+			ProposerAddress: []byte{0xab},
+			AppHash:         []byte{0xcd},
+		},
+		Data: cmttypes.Data{Txs: []cmttypes.Tx{{0x1}, {0x2}}},
+		LastCommit: &cmttypes.Commit{
+			Height:  9,
+			Round:   1,
+			BlockID: cmttypes.BlockID{Hash: []byte{0xef}},
+			Signatures: []cmttypes.CommitSig{
+				{BlockIDFlag: cmttypes.BlockIDFlagCommit},
+				{BlockIDFlag: cmttypes.BlockIDFlagNil},
+				{BlockIDFlag: cmttypes.BlockIDFlagAbsent},
+				{BlockIDFlag: cmttypes.BlockIDFlagCommit},
+			},
+		},
+	}}
+
+	results := &tmcoretypes.ResultBlockResults{TxResults: []*abci.ExecTxResult{{GasUsed: 100}, {GasUsed: 50}}}
+
+	params := &tmcoretypes.ResultConsensusParams{BlockHeight: 10, ConsensusParams: cmttypes.ConsensusParams{Version: cmttypes.VersionParams{App: 3}}}
+
+	resp := s.c.ToRosetta().BlockResponse(block, results, params)
+	s.Require().Equal(int64(2), resp.TxCount)
+	s.Require().Equal(map[string]interface{}{
+		"chain_id":              "cosmoshub-4",
+		"proposer_address":      "AB",
+		"app_hash":              "CD",
+		"header_app_version":    uint64(2),
+		"block_version":         uint64(11),
+		"consensus_app_version": uint64(3),
+		"tx_count":              2,
+		"gas_used":              int64(150),
+		"last_commit": map[string]interface{}{
+			"height":     int64(9),
+			"round":      int32(1),
+			"block_hash": "EF",
+			"signatures": 2,
+			"validators": 4,
+		},
+	}, resp.Metadata)
+
+	// the blocks served without their results only have the header information
+	resp = s.c.ToRosetta().BlockResponse(block, nil, nil)
+	s.Require().NotContains(resp.Metadata, "gas_used")
+	s.Require().NotContains(resp.Metadata, "consensus_app_version")
+	s.Require().Equal(uint64(2), resp.Metadata["header_app_version"])
 }
 
 func TestConverterTestSuite(t *testing.T) {
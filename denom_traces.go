@@ -0,0 +1,335 @@
+package rosetta
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// ibcDenomPrefix prefixes the denoms of the tokens received through ibc
+const ibcDenomPrefix = "ibc/"
+
+// denomTraceKeyPrefix prefixes the hashes of the traces persisted on disk
+const denomTraceKeyPrefix = "trace/"
+
+// denomTracesMethod is the ibc-transfer query listing the denom traces of the chain
+const denomTracesMethod = "/ibc.applications.transfer.v1.Query/DenomTraces"
+
+// keys of the currency metadata filled from the ibc denom traces
+const (
+	CurrencyMetadataIBCBaseDenom = "ibc_base_denom"
+	CurrencyMetadataIBCPath      = "ibc_path"
+	CurrencyMetadataIBCChannel   = "ibc_channel"
+)
+
+// denomTrace is the origin of an ibc denom: the base denom on its source chain and the
+// port and channel pairs it was transferred through, starting from this chain
+type denomTrace struct {
+	Path      string `json:"path"`
+	BaseDenom string `json:"base_denom"`
+}
+
+// channel returns the channel the tokens were received through on this chain
+func (t denomTrace) channel() string {
+	parts := strings.Split(t.Path, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// hash returns the hash of the trace, which is the ibc denom without its prefix
+func (t denomTrace) hash() string {
+	fullPath := t.BaseDenom
+	if t.Path != "" {
+		fullPath = t.Path + "/" + t.BaseDenom
+	}
+	hash := sha256.Sum256([]byte(fullPath))
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
+
+// metadata returns the currency metadata of the trace
+func (t denomTrace) metadata() map[string]interface{} {
+	return map[string]interface{}{
+		CurrencyMetadataIBCBaseDenom: t.BaseDenom,
+		CurrencyMetadataIBCPath:      t.Path,
+		CurrencyMetadataIBCChannel:   t.channel(),
+	}
+}
+
+// denomTraces resolves the ibc denoms to their traces, which are loaded from the ibc-transfer
+// module of the node along the denom metadata. The trace of a denom never changes, since the
+// denom is the hash of the trace, so the traces are kept forever and, if a directory is
+// provided, persisted across restarts. Resolving a denom never queries the node.
+type denomTraces struct {
+	mu     sync.RWMutex
+	traces map[string]denomTrace
+	// unsupported is set if the node does not serve the ibc-transfer query
+	unsupported bool
+	// db is nil if the traces are kept in memory only or the store is closed
+	db *leveldb.DB
+}
+
+// newDenomTraces returns the denom traces persisted in dir, they are kept in memory if dir is empty
+func newDenomTraces(dir string) (*denomTraces, error) {
+	traces := &denomTraces{traces: make(map[string]denomTrace)}
+	if dir == "" {
+		return traces, nil
+	}
+
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("opening denom traces store %s", err.Error()))
+	}
+	iter := db.NewIterator(util.BytesPrefix([]byte(denomTraceKeyPrefix)), nil)
+	for iter.Next() {
+		var trace denomTrace
+		if err := json.Unmarshal(iter.Value(), &trace); err != nil {
+			continue
+		}
+		traces.traces[strings.TrimPrefix(string(iter.Key()), denomTraceKeyPrefix)] = trace
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		_ = db.Close()
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("loading denom traces %s", err.Error()))
+	}
+	traces.db = db
+	return traces, nil
+}
+
+// hashOf returns the hash of an ibc denom, false if the denom is not an ibc one
+func hashOf(denom string) (string, bool) {
+	hash, ok := strings.CutPrefix(denom, ibcDenomPrefix)
+	if !ok || hash == "" {
+		return "", false
+	}
+	return strings.ToUpper(hash), true
+}
+
+// trace returns the trace of an ibc denom, false if the denom is not an ibc one or its trace
+// is not loaded
+func (t *denomTraces) trace(denom string) (denomTrace, bool) {
+	hash, ok := hashOf(denom)
+	if !ok {
+		return denomTrace{}, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	trace, ok := t.traces[hash]
+	return trace, ok
+}
+
+// pending reports whether denom is an ibc denom whose trace may be loaded by the next refresh
+func (t *denomTraces) pending(denom string) bool {
+	hash, ok := hashOf(denom)
+	if !ok {
+		return false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok = t.traces[hash]
+	return !ok && !t.unsupported
+}
+
+// refresh loads the traces of the chain from the node, the traces already loaded are kept
+func (t *denomTraces) refresh(ctx context.Context, invoker grpc.ClientConnInterface) error {
+	var nextKey []byte
+	for {
+		res := new(queryDenomTracesResponse)
+		err := invoker.Invoke(ctx, denomTracesMethod, &queryDenomTracesRequest{Key: nextKey}, res)
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+			// the chain has no ibc-transfer module, the ibc denoms are not resolved
+			t.mu.Lock()
+			t.unsupported = true
+			t.mu.Unlock()
+			return nil
+		case err != nil:
+			return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting denom traces %s", err.Error()))
+		}
+		t.add(res.DenomTraces)
+		if len(res.NextKey) == 0 {
+			t.mu.Lock()
+			t.unsupported = false
+			t.mu.Unlock()
+			return nil
+		}
+		nextKey = res.NextKey
+	}
+}
+
+// add keeps the new traces and persists them on disk, failures to persist are ignored as the
+// traces are loaded again at the next refresh
+func (t *denomTraces) add(traces []denomTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, trace := range traces {
+		if trace.BaseDenom == "" || trace.Path == "" {
+			continue
+		}
+		hash := trace.hash()
+		if _, ok := t.traces[hash]; ok {
+			continue
+		}
+		t.traces[hash] = trace
+		if t.db == nil {
+			continue
+		}
+		value, err := json.Marshal(trace)
+		if err != nil {
+			continue
+		}
+		_ = t.db.Put([]byte(denomTraceKeyPrefix+hash), value, nil)
+	}
+}
+
+// Close closes the denom traces store, the loaded traces are still resolved but no longer persisted
+func (t *denomTraces) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.db == nil {
+		return nil
+	}
+	err := t.db.Close()
+	t.db = nil
+	return err
+}
+
+// queryDenomTracesRequest is the ibc.applications.transfer.v1.QueryDenomTracesRequest message,
+// it is encoded by hand as ibc-go is not a dependency of rosetta. Key is the key of the page.
+type queryDenomTracesRequest struct {
+	Key []byte
+}
+
+func (m *queryDenomTracesRequest) Reset()         { *m = queryDenomTracesRequest{} }
+func (m *queryDenomTracesRequest) String() string { return hex.EncodeToString(m.Key) }
+func (*queryDenomTracesRequest) ProtoMessage()    {}
+
+func (m *queryDenomTracesRequest) Marshal() ([]byte, error) {
+	if len(m.Key) == 0 {
+		return nil, nil
+	}
+	pagination := protowire.AppendTag(nil, 1, protowire.BytesType)
+	pagination = protowire.AppendBytes(pagination, m.Key)
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	return protowire.AppendBytes(b, pagination), nil
+}
+
+func (m *queryDenomTracesRequest) Unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, value []byte) error {
+		if num != 1 {
+			return nil
+		}
+		return consumeFields(value, func(num protowire.Number, value []byte) error {
+			if num == 1 {
+				m.Key = append([]byte(nil), value...)
+			}
+			return nil
+		})
+	})
+}
+
+// queryDenomTracesResponse is the ibc.applications.transfer.v1.QueryDenomTracesResponse message,
+// NextKey is the key of the next page, empty on the last page
+type queryDenomTracesResponse struct {
+	DenomTraces []denomTrace
+	NextKey     []byte
+}
+
+func (m *queryDenomTracesResponse) Reset() { *m = queryDenomTracesResponse{} }
+func (m *queryDenomTracesResponse) String() string {
+	return fmt.Sprintf("%d denom traces", len(m.DenomTraces))
+}
+func (*queryDenomTracesResponse) ProtoMessage() {}
+
+func (m *queryDenomTracesResponse) Marshal() ([]byte, error) {
+	var b []byte
+	for _, trace := range m.DenomTraces {
+		var value []byte
+		value = protowire.AppendTag(value, 1, protowire.BytesType)
+		value = protowire.AppendString(value, trace.Path)
+		value = protowire.AppendTag(value, 2, protowire.BytesType)
+		value = protowire.AppendString(value, trace.BaseDenom)
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, value)
+	}
+	if len(m.NextKey) != 0 {
+		pagination := protowire.AppendTag(nil, 1, protowire.BytesType)
+		pagination = protowire.AppendBytes(pagination, m.NextKey)
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, pagination)
+	}
+	return b, nil
+}
+
+func (m *queryDenomTracesResponse) Unmarshal(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, value []byte) error {
+		switch num {
+		case 1:
+			var trace denomTrace
+			err := consumeFields(value, func(num protowire.Number, value []byte) error {
+				switch num {
+				case 1:
+					trace.Path = string(value)
+				case 2:
+					trace.BaseDenom = string(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			m.DenomTraces = append(m.DenomTraces, trace)
+		case 2:
+			return consumeFields(value, func(num protowire.Number, value []byte) error {
+				if num == 1 {
+					m.NextKey = append([]byte(nil), value...)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+// consumeFields calls fn with the length delimited fields of a protobuf message, skipping the others
+func consumeFields(b []byte, fn func(num protowire.Number, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+			continue
+		}
+		value, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, value); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
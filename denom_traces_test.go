@@ -0,0 +1,129 @@
+package rosetta
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	bank "cosmossdk.io/x/bank/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const atomTraceHash = "27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"
+
+// denomTracesServer serves the ibc-transfer denom traces, one trace per page, and counts the queries
+type denomTracesServer struct {
+	traces  []denomTrace
+	queries atomic.Int32
+}
+
+func (s *denomTracesServer) denomTraces(req *queryDenomTracesRequest) (*queryDenomTracesResponse, error) {
+	s.queries.Add(1)
+	i := 0
+	if len(req.Key) > 0 {
+		i = int(req.Key[0])
+	}
+	res := &queryDenomTracesResponse{DenomTraces: s.traces[i : i+1]}
+	if i+1 < len(s.traces) {
+		res.NextKey = []byte{byte(i + 1)}
+	}
+	return res, nil
+}
+
+// serveDenomTraces returns a connection to a gRPC server serving the denom traces, the server
+// has no services if srv is nil. The messages go through the gRPC codec like with a node.
+func serveDenomTraces(t *testing.T, srv *denomTracesServer) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	if srv != nil {
+		server.RegisterService(&grpc.ServiceDesc{
+			ServiceName: "ibc.applications.transfer.v1.Query",
+			HandlerType: (*interface{})(nil),
+			Methods: []grpc.MethodDesc{{
+				MethodName: "DenomTraces",
+				Handler: func(srv interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+					req := new(queryDenomTracesRequest)
+					if err := dec(req); err != nil {
+						return nil, err
+					}
+					return srv.(*denomTracesServer).denomTraces(req)
+				},
+			}},
+		}, srv)
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestClient_DenomTraces(t *testing.T) {
+	dir := t.TempDir()
+	srv := &denomTracesServer{traces: []denomTrace{
+		{Path: "transfer/channel-0", BaseDenom: "uatom"},
+		{Path: "transfer/channel-141", BaseDenom: "uosmo"},
+	}}
+	bankClient := denomsMetadataClient{metadata: []bank.Metadata{{Base: "stake", Display: "stake"}}}
+	client := newTestClient(t, &Config{DenomTracesDir: dir}, &node{bank: bankClient, invoker: serveDenomTraces(t, srv)})
+
+	// the traces are not queried to resolve a denom, they are loaded along the metadata
+	denom := "ibc/" + atomTraceHash
+	require.Nil(t, client.Currency(denom).Metadata)
+	require.Zero(t, srv.queries.Load())
+	require.NoError(t, client.refreshDenoms(context.Background()))
+	require.Equal(t, int32(2), srv.queries.Load())
+
+	// the symbol is unchanged and the metadata holds the trace
+	currency := client.Currency(denom)
+	require.Equal(t, denom, currency.Symbol)
+	require.Equal(t, map[string]interface{}{
+		CurrencyMetadataIBCBaseDenom: "uatom",
+		CurrencyMetadataIBCPath:      "transfer/channel-0",
+		CurrencyMetadataIBCChannel:   "channel-0",
+	}, currency.Metadata)
+	require.Equal(t, "uosmo", client.Currency("ibc/" + srv.traces[1].hash()).Metadata[CurrencyMetadataIBCBaseDenom])
+	require.Nil(t, client.Currency("ibc/"+atomTraceHash[1:]+"0").Metadata)
+
+	coins := sdk.NewCoins(sdk.NewInt64Coin(denom, 10), sdk.NewInt64Coin("stake", 1))
+	amounts := client.converter.ToRosetta().Amounts(coins, coins)
+	require.Equal(t, currency, amounts[0].Currency)
+	require.NotContains(t, amounts[1].Currency.Metadata, CurrencyMetadataIBCBaseDenom)
+
+	// the traces are still resolved after the store is closed
+	require.NoError(t, client.denoms.traces.Close())
+	require.Equal(t, currency, client.Currency(denom))
+	require.NoError(t, client.refreshDenoms(context.Background()))
+
+	// the traces are persisted across restarts
+	client = newTestClient(t, &Config{DenomTracesDir: dir}, &node{bank: bankClient, invoker: serveDenomTraces(t, nil)})
+	defer client.denoms.traces.Close()
+	require.Equal(t, currency, client.Currency(denom))
+
+	// the chains without the ibc-transfer module have no traces
+	require.NoError(t, client.refreshDenoms(context.Background()))
+	require.Nil(t, client.Currency("ibc/"+atomTraceHash[1:]+"0").Metadata)
+	require.False(t, client.denoms.traces.pending("ibc/"+atomTraceHash[1:]+"0"))
+}
+
+func TestDenomTrace_hash(t *testing.T) {
+	require.Equal(t, atomTraceHash, denomTrace{Path: "transfer/channel-0", BaseDenom: "uatom"}.hash())
+}
+
+func TestDenomTrace_channel(t *testing.T) {
+	require.Equal(t, "channel-141", denomTrace{Path: "transfer/channel-141/transfer/channel-0", BaseDenom: "uosmo"}.channel())
+	require.Empty(t, denomTrace{BaseDenom: "uatom"}.channel())
+}
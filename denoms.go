@@ -0,0 +1,246 @@
+package rosetta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	bank "cosmossdk.io/x/bank/types"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// keys of the currency metadata filled from the bank denom metadata
+const (
+	CurrencyMetadataBaseDenom    = "base_denom"
+	CurrencyMetadataDisplayDenom = "display_denom"
+	CurrencyMetadataDescription  = "description"
+)
+
+// denomRegistry holds the bank metadata of the denoms, which fills the decimals and the metadata
+// of the currencies. The metadata queried from the node is refreshed periodically, the metadata
+// loaded from the configuration takes precedence for the chains without on chain metadata.
+// The metadata of the ibc denoms also holds their trace.
+//
+// A currency is part of the identity of the amounts, so once served with metadata after the
+// metadata was loaded it never changes, even if the metadata changes on chain. The currencies
+// without metadata are not kept, so that they get their metadata once it is set on chain. The served currencies are
+// persisted along the disk cache, so that they match the cached responses across restarts.
+type denomRegistry struct {
+	mu sync.RWMutex
+	// onChain is the metadata queried from the node, keyed by base denom
+	onChain map[string]bank.Metadata
+	// refreshedAt is the time onChain was queried at, zero if never queried
+	refreshedAt time.Time
+	// overrides is the metadata loaded from the configuration, keyed by base denom
+	overrides map[string]bank.Metadata
+	// traces resolves the ibc denoms, nil if they are not resolved
+	traces *denomTraces
+	// served are the currencies with metadata served once the metadata was loaded,
+	// keyed by denom, they are shared and must not be modified
+	served map[string]*rosettatypes.Currency
+	// store persists the served currencies, nil if they are kept in memory only
+	store *responseCache
+}
+
+// newDenomRegistry returns a registry with the given metadata overriding the on chain one,
+// the ibc denoms are resolved by traces and the served currencies persisted by store unless nil
+func newDenomRegistry(overrides []bank.Metadata, traces *denomTraces, store *responseCache) *denomRegistry {
+	r := &denomRegistry{
+		overrides: make(map[string]bank.Metadata, len(overrides)),
+		traces:    traces,
+		served:    make(map[string]*rosettatypes.Currency),
+		store:     store,
+	}
+	for _, metadata := range overrides {
+		r.overrides[metadata.Base] = metadata
+	}
+	return r
+}
+
+// loadDenomMetadataFile reads the metadata overrides from a json file containing a list of
+// bank denom metadata, in the format of the denom_metadata of the bank genesis
+func loadDenomMetadataFile(path string) ([]bank.Metadata, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("reading denom metadata file %s", err.Error()))
+	}
+	var metadata []bank.Metadata
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("decoding denom metadata file %s", err.Error()))
+	}
+	for _, m := range metadata {
+		if m.Base == "" {
+			return nil, crgerrs.WrapError(crgerrs.ErrConfig, "denom metadata without base denom")
+		}
+	}
+	return metadata, nil
+}
+
+// set replaces the metadata queried from the node
+func (r *denomRegistry) set(metadata []bank.Metadata, refreshedAt time.Time) {
+	onChain := make(map[string]bank.Metadata, len(metadata))
+	for _, m := range metadata {
+		onChain[m.Base] = m
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onChain = onChain
+	r.refreshedAt = refreshedAt
+}
+
+// stale returns true if the metadata was never queried or was queried more than interval ago
+func (r *denomRegistry) stale(interval time.Duration) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.refreshedAt.IsZero() || time.Since(r.refreshedAt) >= interval
+}
+
+// hasOverrides reports whether metadata is loaded from the configuration
+func (r *denomRegistry) hasOverrides() bool {
+	return len(r.overrides) != 0
+}
+
+// currency returns the currency of denom, its decimals are the exponent of the display unit
+// and they are 0 if the denom has no metadata. The symbol is always the denom. The currency
+// served for a denom with metadata does not change once the metadata was loaded, it must not
+// be modified.
+func (r *denomRegistry) currency(denom string) *rosettatypes.Currency {
+	r.mu.RLock()
+	currency, served := r.served[denom]
+	loaded := !r.refreshedAt.IsZero()
+	r.mu.RUnlock()
+	if served {
+		return currency
+	}
+
+	var stored bool
+	if r.store != nil {
+		currency, stored = r.store.currency(denom)
+	}
+	if !stored {
+		currency = r.newCurrency(denom)
+	}
+	// the currencies served before the metadata is loaded or without metadata are not kept, as
+	// their metadata may be set later, neither are the ibc currencies whose trace is not loaded yet
+	if !stored && (!loaded || currency.Metadata == nil || r.traces != nil && r.traces.pending(denom)) {
+		return currency
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if served, ok := r.served[denom]; ok {
+		return served
+	}
+	r.served[denom] = currency
+	if !stored && r.store != nil {
+		r.store.addCurrency(currency)
+	}
+	return currency
+}
+
+// newCurrency returns the currency of denom built from its metadata and its trace
+func (r *denomRegistry) newCurrency(denom string) *rosettatypes.Currency {
+	r.mu.RLock()
+	metadata, ok := r.overrides[denom]
+	if !ok {
+		metadata, ok = r.onChain[denom]
+	}
+	r.mu.RUnlock()
+
+	currency := &rosettatypes.Currency{Symbol: denom}
+	if ok {
+		currency.Decimals = displayExponent(metadata)
+		currency.Metadata = map[string]interface{}{
+			CurrencyMetadataBaseDenom:    metadata.Base,
+			CurrencyMetadataDisplayDenom: metadata.Display,
+		}
+		if metadata.Description != "" {
+			currency.Metadata[CurrencyMetadataDescription] = metadata.Description
+		}
+	}
+	if r.traces == nil {
+		return currency
+	}
+	if trace, ok := r.traces.trace(denom); ok {
+		if currency.Metadata == nil {
+			currency.Metadata = make(map[string]interface{})
+		}
+		maps.Copy(currency.Metadata, trace.metadata())
+	}
+	return currency
+}
+
+// displayExponent returns the exponent of the display unit of the metadata, 0 if not listed
+func displayExponent(metadata bank.Metadata) int32 {
+	for _, unit := range metadata.DenomUnits {
+		if unit != nil && (unit.Denom == metadata.Display || slices.Contains(unit.Aliases, metadata.Display)) {
+			return int32(unit.Exponent)
+		}
+	}
+	return 0
+}
+
+// Currency returns the currency of denom with the decimals and the metadata of the denom
+func (c *Client) Currency(denom string) *rosettatypes.Currency {
+	return c.denoms.currency(denom)
+}
+
+// refreshDenoms queries the metadata of every denom and the ibc denom traces from the node, the
+// traces are loaded first so that the currencies served once the metadata is set hold their trace
+func (c *Client) refreshDenoms(ctx context.Context) error {
+	n, err := c.node()
+	if err != nil {
+		return err
+	}
+	metadata, err := denomsMetadata(ctx, n)
+	if err != nil {
+		return err
+	}
+	if c.denoms.traces != nil && n.invoker != nil {
+		if err := c.denoms.traces.refresh(ctx, n.invoker); err != nil {
+			return err
+		}
+	}
+	c.denoms.set(metadata, time.Now())
+	return nil
+}
+
+// denomsMetadata queries the metadata of every denom from the node
+func denomsMetadata(ctx context.Context, n *node) ([]bank.Metadata, error) {
+	var (
+		metadata []bank.Metadata
+		nextKey  []byte
+	)
+	for {
+		res, err := n.bank.DenomsMetadata(ctx, &bank.QueryDenomsMetadataRequest{
+			Pagination: &query.PageRequest{Key: nextKey},
+		})
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+			// the chain has no on chain metadata, only the overrides are used
+			return nil, nil
+		case err != nil:
+			return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting denoms metadata %s", err.Error()))
+		}
+		metadata = append(metadata, res.Metadatas...)
+		nextKey = res.GetPagination().GetNextKey()
+		if len(nextKey) == 0 {
+			return metadata, nil
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package rosetta
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	tmrpc "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	bank "cosmossdk.io/x/bank/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+)
+
+// denomsMetadataClient serves the denom metadata, one denom per page
+type denomsMetadataClient struct {
+	bank.QueryClient
+	metadata []bank.Metadata
+}
+
+func (c denomsMetadataClient) DenomsMetadata(_ context.Context, req *bank.QueryDenomsMetadataRequest, _ ...grpc.CallOption) (*bank.QueryDenomsMetadataResponse, error) {
+	i := 0
+	if len(req.Pagination.Key) > 0 {
+		i = int(req.Pagination.Key[0])
+	}
+	res := &bank.QueryDenomsMetadataResponse{Metadatas: c.metadata[i : i+1], Pagination: &query.PageResponse{}}
+	if i+1 < len(c.metadata) {
+		res.Pagination.NextKey = []byte{byte(i + 1)}
+	}
+	return res, nil
+}
+
+func TestClient_Currency(t *testing.T) {
+	atom := bank.Metadata{
+		Description: "The native staking token of the Cosmos Hub.",
+		DenomUnits: []*bank.DenomUnit{
+			{Denom: "uatom", Exponent: 0},
+			{Denom: "matom", Exponent: 3},
+			{Denom: "atom", Exponent: 6},
+		},
+		Base:    "uatom",
+		Display: "atom",
+	}
+	osmo := bank.Metadata{
+		DenomUnits: []*bank.DenomUnit{{Denom: "uosmo"}, {Denom: "OSMO", Exponent: 6, Aliases: []string{"osmo"}}},
+		Base:       "uosmo",
+		Display:    "osmo",
+	}
+	// the chain has no metadata for stake, it is set in the configuration
+	file := filepath.Join(t.TempDir(), "denoms.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[{"base":"stake","display":"STAKE","denom_units":[{"denom":"stake"},{"denom":"STAKE","exponent":18}]}]`), 0o600))
+
+	bankClient := denomsMetadataClient{metadata: []bank.Metadata{atom, osmo}}
+	client := newTestClient(t, &Config{DenomMetadataFile: file}, &node{bank: bankClient})
+
+	require.True(t, client.denoms.stale(client.config.DenomMetadataRefresh))
+	require.Equal(t, &rosettatypes.Currency{Symbol: "uatom"}, client.Currency("uatom"))
+	require.NoError(t, client.refreshDenoms(context.Background()))
+	require.False(t, client.denoms.stale(client.config.DenomMetadataRefresh))
+	require.True(t, client.denoms.stale(0))
+
+	require.Equal(t, &rosettatypes.Currency{
+		Symbol:   "uatom",
+		Decimals: 6,
+		Metadata: map[string]interface{}{
+			CurrencyMetadataBaseDenom:    "uatom",
+			CurrencyMetadataDisplayDenom: "atom",
+			CurrencyMetadataDescription:  "The native staking token of the Cosmos Hub.",
+		},
+	}, client.Currency("uatom"))
+	require.Equal(t, int32(6), client.Currency("uosmo").Decimals)
+	require.Equal(t, int32(18), client.Currency("stake").Decimals)
+	require.Equal(t, &rosettatypes.Currency{Symbol: "unknown"}, client.Currency("unknown"))
+
+	// the currencies of amounts and balance operations are filled the same way
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uatom", 10), sdk.NewInt64Coin("unknown", 1))
+	for _, amount := range client.converter.ToRosetta().Amounts(coins, coins) {
+		require.Equal(t, client.Currency(amount.Currency.Symbol), amount.Currency)
+	}
+	addr := sdk.AccAddress("address").String()
+	ops, err := client.converter.ToRosetta().BalanceOps(StatusTxSuccess, []abcitypes.Event{{
+		Type: bank.EventTypeCoinReceived,
+		Attributes: []abcitypes.EventAttribute{
+			{Key: bank.AttributeKeyReceiver, Value: addr},
+			{Key: sdk.AttributeKeyAmount, Value: "10uatom"},
+		},
+	}})
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, client.Currency("uatom"), ops[0].Amount.Currency)
+
+	// the served currencies do not change with the metadata, the other denoms get the new metadata,
+	// including the denoms served before their metadata was set
+	require.Zero(t, client.Currency("ujuno").Decimals)
+	juno := bank.Metadata{DenomUnits: []*bank.DenomUnit{{Denom: "ujuno"}, {Denom: "juno", Exponent: 6}}, Base: "ujuno", Display: "juno"}
+	bankClient.metadata = []bank.Metadata{osmo, juno}
+	client.pool.nodes[0].conn.Store(&node{bank: bankClient})
+	require.NoError(t, client.refreshDenoms(context.Background()))
+	require.Equal(t, int32(6), client.Currency("uatom").Decimals)
+	require.Equal(t, int32(6), client.Currency("ujuno").Decimals)
+	require.Equal(t, int32(18), client.Currency("stake").Decimals)
+}
+
+func TestClient_CurrencyPersisted(t *testing.T) {
+	atom := bank.Metadata{DenomUnits: []*bank.DenomUnit{{Denom: "uatom"}, {Denom: "atom", Exponent: 6}}, Base: "uatom", Display: "atom"}
+	dir := t.TempDir()
+	newClient := func(metadata ...bank.Metadata) *Client {
+		client := newTestClient(t, &Config{CacheSize: 10, CacheDir: dir}, &node{bank: denomsMetadataClient{metadata: metadata}})
+		require.NoError(t, client.refreshDenoms(context.Background()))
+		return client
+	}
+
+	client := newClient(atom)
+	require.Equal(t, int32(6), client.Currency("uatom").Decimals)
+	require.NoError(t, client.cache.Close())
+	require.NoError(t, client.events.Close())
+
+	// the currencies served before a restart are served after it, whatever the metadata
+	atom.DenomUnits[1].Exponent = 9
+	client = newClient(atom)
+	defer client.events.Close()
+	defer client.cache.Close()
+	require.Equal(t, int32(6), client.Currency("uatom").Decimals)
+}
+
+// readyClient is a synced node whose denom metadata query fails with err
+type readyClient struct {
+	tmrpc.Client
+	bank.QueryClient
+	err error
+}
+
+func (readyClient) Health(context.Context) (*coretypes.ResultHealth, error) {
+	return &coretypes.ResultHealth{}, nil
+}
+
+func (readyClient) Status(context.Context) (*coretypes.ResultStatus, error) {
+	return &coretypes.ResultStatus{SyncInfo: coretypes.SyncInfo{LatestBlockHeight: 10}}, nil
+}
+
+func (readyClient) TotalSupply(context.Context, *bank.QueryTotalSupplyRequest, ...grpc.CallOption) (*bank.QueryTotalSupplyResponse, error) {
+	return &bank.QueryTotalSupplyResponse{}, nil
+}
+
+func (c readyClient) DenomsMetadata(context.Context, *bank.QueryDenomsMetadataRequest, ...grpc.CallOption) (*bank.QueryDenomsMetadataResponse, error) {
+	return nil, c.err
+}
+
+func TestClient_Ready(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "denoms.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[{"base":"stake","display":"STAKE","denom_units":[{"denom":"stake"},{"denom":"STAKE","exponent":18}]}]`), 0o600))
+	failing := readyClient{err: errors.New("unavailable")}
+
+	// the client is not ready until the metadata is loaded
+	client := newTestClient(t, &Config{}, &node{tmRPC: failing, bank: failing})
+	require.Error(t, client.Ready())
+
+	// unless metadata is configured
+	client = newTestClient(t, &Config{DenomMetadataFile: file}, &node{tmRPC: failing, bank: failing})
+	require.NoError(t, client.Ready())
+	require.True(t, client.denoms.stale(client.config.DenomMetadataRefresh))
+	require.Equal(t, int32(18), client.Currency("stake").Decimals)
+
+	// the chains without the metadata query have no on chain metadata
+	unimplemented := readyClient{err: status.Error(codes.Unimplemented, "unknown method")}
+	client = newTestClient(t, &Config{}, &node{tmRPC: unimplemented, bank: unimplemented})
+	require.NoError(t, client.Ready())
+	require.False(t, client.denoms.stale(client.config.DenomMetadataRefresh))
+}
@@ -0,0 +1,164 @@
+package rosetta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/syndtr/goleveldb/leveldb"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+const (
+	// maxEventsLimit is the maximum number of block events returned by /events/blocks
+	maxEventsLimit = 100
+	// maxBlockchainInfoBlocks is the maximum number of blocks returned by CometBFT blockchain
+	maxBlockchainInfoBlocks = 20
+)
+
+// baseHeightKey is the key of the persisted base height
+var baseHeightKey = []byte("events/base_height")
+
+// blockEvents maps the sequence numbers of the /events/blocks stream to block heights.
+// CometBFT has instant finality so blocks are only ever added: the event of sequence s adds
+// the block at height base+s, base being the oldest block served when the stream started.
+// The base height is persisted so that the sequences do not change across restarts, or when
+// the nodes prune their oldest blocks.
+type blockEvents struct {
+	mu   sync.Mutex
+	base int64
+	// db is nil once the events store is closed
+	db *leveldb.DB
+}
+
+// newBlockEvents loads the base height persisted in dir
+func newBlockEvents(dir string) (*blockEvents, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("opening events store %s", err.Error()))
+	}
+	events := &blockEvents{db: db}
+	value, err := db.Get(baseHeightKey, nil)
+	switch {
+	case errors.Is(err, leveldb.ErrNotFound):
+	case err != nil:
+		_ = db.Close()
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("reading events base height %s", err.Error()))
+	default:
+		events.base, err = strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			_ = db.Close()
+			return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("invalid events base height %s", err.Error()))
+		}
+	}
+	return events, nil
+}
+
+// baseHeight returns the height of the block added by the first event, the first time
+// it is called it starts the stream at the height returned by oldest
+func (e *blockEvents) baseHeight(ctx context.Context, oldest func(ctx context.Context) (int64, error)) (int64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.db == nil {
+		return 0, crgerrs.WrapError(crgerrs.ErrOnlineClient, "events store is closed")
+	}
+	if e.base > 0 {
+		return e.base, nil
+	}
+
+	base, err := oldest(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := e.db.Put(baseHeightKey, []byte(strconv.FormatInt(base, 10)), nil); err != nil {
+		return 0, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("persisting events base height %s", err.Error()))
+	}
+	e.base = base
+	return base, nil
+}
+
+// Close closes the events store, the events cannot be served afterwards
+func (e *blockEvents) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.db == nil {
+		return nil
+	}
+	err := e.db.Close()
+	e.db = nil
+	return err
+}
+
+// BlockEvents returns up to limit block_added events from the offset sequence, or the last limit
+// events if offset is nil, and the maximum sequence which is the event adding the tip of the chain
+func (c *Client) BlockEvents(ctx context.Context, offset, limit *int64) (int64, []*rosettatypes.BlockEvent, error) {
+	if c.events == nil {
+		return 0, nil, crgerrs.WrapError(crgerrs.ErrNotImplemented, fmt.Sprintf("block events require --%s or --%s to persist their sequences", FlagEventsDir, FlagCacheDir))
+	}
+	base, err := c.events.baseHeight(ctx, func(ctx context.Context) (int64, error) {
+		oldest, err := c.OldestBlock(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return oldest.Block.Index, nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	conn, err := c.node()
+	if err != nil {
+		return 0, nil, err
+	}
+	status, err := conn.tmRPC.Status(ctx)
+	if err != nil {
+		return 0, nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting node status %s", err.Error()))
+	}
+	maxSequence := status.SyncInfo.LatestBlockHeight - base
+	if maxSequence < 0 {
+		return 0, nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("node tip %d is below the first event block %d", status.SyncInfo.LatestBlockHeight, base))
+	}
+
+	n := int64(maxEventsLimit)
+	if limit != nil && *limit > 0 && *limit < maxEventsLimit {
+		n = *limit
+	}
+	start := max(maxSequence-n+1, 0)
+	if offset != nil {
+		start = *offset
+	}
+	end := min(start+n-1, maxSequence)
+
+	events := make([]*rosettatypes.BlockEvent, 0, max(end-start+1, 0))
+	for from := start; from <= end; from += maxBlockchainInfoBlocks {
+		minHeight, maxHeight := base+from, base+min(from+maxBlockchainInfoBlocks-1, end)
+		conn, err := c.nodeAt(&minHeight)
+		if err != nil {
+			return 0, nil, err
+		}
+		info, err := conn.tmRPC.BlockchainInfo(ctx, minHeight, maxHeight)
+		if err != nil {
+			return 0, nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting blocks %d to %d %s", minHeight, maxHeight, err.Error()))
+		}
+		if int64(len(info.BlockMetas)) != maxHeight-minHeight+1 {
+			return 0, nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("got %d blocks from %d to %d", len(info.BlockMetas), minHeight, maxHeight))
+		}
+		// the blocks are returned from the highest
+		for i := len(info.BlockMetas) - 1; i >= 0; i-- {
+			meta := info.BlockMetas[i]
+			events = append(events, &rosettatypes.BlockEvent{
+				Sequence: meta.Header.Height - base,
+				BlockIdentifier: &rosettatypes.BlockIdentifier{
+					Index: meta.Header.Height,
+					Hash:  meta.BlockID.Hash.String(),
+				},
+				Type: rosettatypes.ADDED,
+			})
+		}
+	}
+	return maxSequence, events, nil
+}
@@ -0,0 +1,107 @@
+package rosetta
+
+import (
+	"context"
+	"testing"
+
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	tmrpc "github.com/cometbft/cometbft/rpc/client"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/stretchr/testify/require"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// chainClient serves the blocks between earliest and latest, the hash of a block is its height
+type chainClient struct {
+	tmrpc.Client
+	earliest, latest int64
+}
+
+func (c *chainClient) Status(context.Context) (*coretypes.ResultStatus, error) {
+	return &coretypes.ResultStatus{SyncInfo: coretypes.SyncInfo{EarliestBlockHeight: c.earliest, LatestBlockHeight: c.latest}}, nil
+}
+
+func (c *chainClient) Block(_ context.Context, height *int64) (*coretypes.ResultBlock, error) {
+	return &coretypes.ResultBlock{Block: &cmttypes.Block{Header: cmttypes.Header{Height: *height}}}, nil
+}
+
+func (c *chainClient) BlockchainInfo(_ context.Context, minHeight, maxHeight int64) (*coretypes.ResultBlockchainInfo, error) {
+	info := &coretypes.ResultBlockchainInfo{LastHeight: c.latest}
+	for height := min(maxHeight, c.latest); height >= max(minHeight, c.earliest); height-- {
+		info.BlockMetas = append(info.BlockMetas, &cmttypes.BlockMeta{
+			BlockID: cmttypes.BlockID{Hash: []byte{byte(height)}},
+			Header:  cmttypes.Header{Height: height},
+		})
+	}
+	return info, nil
+}
+
+func TestClient_BlockEvents(t *testing.T) {
+	dir := t.TempDir()
+	rpc := &chainClient{earliest: 10, latest: 50}
+	client := newTestClient(t, &Config{EventsDir: dir}, &node{tmRPC: rpc})
+	ctx := context.Background()
+	int64Ptr := func(v int64) *int64 { return &v }
+	heights := func(events []*rosettatypes.BlockEvent) []int64 {
+		heights := make([]int64, len(events))
+		for i, event := range events {
+			require.Equal(t, rosettatypes.ADDED, event.Type)
+			require.Equal(t, event.BlockIdentifier.Index-10, event.Sequence)
+			heights[i] = event.BlockIdentifier.Index
+		}
+		return heights
+	}
+
+	// the stream starts at the oldest block
+	maxSequence, events, err := client.BlockEvents(ctx, int64Ptr(0), int64Ptr(3))
+	require.NoError(t, err)
+	require.Equal(t, int64(40), maxSequence)
+	require.Equal(t, []int64{10, 11, 12}, heights(events))
+	require.Equal(t, "0A", events[0].BlockIdentifier.Hash)
+
+	// the last events
+	_, events, err = client.BlockEvents(ctx, nil, int64Ptr(2))
+	require.NoError(t, err)
+	require.Equal(t, []int64{49, 50}, heights(events))
+
+	// pages larger than the CometBFT blockchain info limit, up to the tip
+	_, events, err = client.BlockEvents(ctx, int64Ptr(5), nil)
+	require.NoError(t, err)
+	require.Len(t, events, 36)
+	require.Equal(t, int64(15), events[0].BlockIdentifier.Index)
+	require.Equal(t, int64(50), events[35].BlockIdentifier.Index)
+
+	_, events, err = client.BlockEvents(ctx, int64Ptr(41), nil)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	// the sequences do not change once the node prunes blocks and rosetta restarts
+	require.NoError(t, client.events.Close())
+	rpc.earliest = 20
+	client = newTestClient(t, &Config{EventsDir: dir}, &node{tmRPC: rpc})
+	maxSequence, events, err = client.BlockEvents(ctx, int64Ptr(15), int64Ptr(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(40), maxSequence)
+	require.Equal(t, []int64{25}, heights(events))
+
+	// the events are not served once the client is closed
+	require.NoError(t, client.events.Close())
+	_, _, err = client.BlockEvents(ctx, int64Ptr(15), int64Ptr(1))
+	require.ErrorIs(t, err, crgerrs.ErrOnlineClient)
+
+	// the events are not served if the sequences cannot be persisted
+	client = newTestClient(t, &Config{}, &node{tmRPC: rpc})
+	_, _, err = client.BlockEvents(ctx, int64Ptr(0), int64Ptr(1))
+	require.ErrorIs(t, err, crgerrs.ErrNotImplemented)
+
+	// the sequences are persisted next to the disk cache by default
+	client = newTestClient(t, &Config{CacheSize: 1, CacheDir: t.TempDir()}, &node{tmRPC: rpc})
+	defer client.cache.Close()
+	defer client.events.Close()
+	_, events, err = client.BlockEvents(ctx, int64Ptr(0), int64Ptr(1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, int64(20), events[0].BlockIdentifier.Index)
+}
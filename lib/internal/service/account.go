@@ -42,7 +42,12 @@ func (on OnlineNetwork) AccountBalance(ctx context.Context, request *types.Accou
 		}
 	}
 
-	accountCoins, err := on.client.Balances(ctx, request.AccountIdentifier.Address, &height)
+	var accountCoins []*types.Amount
+	if subAccount := request.AccountIdentifier.SubAccount; subAccount != nil {
+		accountCoins, err = on.client.SubAccountBalances(ctx, request.AccountIdentifier.Address, subAccount, &height, request.Currencies)
+	} else {
+		accountCoins, err = on.client.Balances(ctx, request.AccountIdentifier.Address, &height, request.Currencies)
+	}
 	if err != nil {
 		return nil, errors.ToRosetta(err)
 	}
@@ -59,7 +59,7 @@ func (on OnlineNetwork) Block(ctx context.Context, request *types.BlockRequest)
 			ParentBlockIdentifier: blockResponse.ParentBlock,
 			Timestamp:             blockResponse.MillisecondTimestamp,
 			Transactions:          blockResponse.Transactions,
-			Metadata:              nil,
+			Metadata:              blockResponse.Metadata,
 		},
 		OtherTransactions: nil,
 	}, nil
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+
+	"github.com/cosmos/rosetta/lib/errors"
+)
+
+// Call invokes the requested gRPC query of the node, the results are not idempotent
+// as the queries are served at the latest height
+func (on OnlineNetwork) Call(ctx context.Context, request *types.CallRequest) (*types.CallResponse, *types.Error) {
+	result, err := on.client.Call(ctx, request.Method, request.Parameters)
+	if err != nil {
+		return nil, errors.ToRosetta(err)
+	}
+
+	return &types.CallResponse{
+		Result:     result,
+		Idempotent: false,
+	}, nil
+}
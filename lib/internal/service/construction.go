@@ -36,8 +36,8 @@ func (on OnlineNetwork) ConstructionCombine(ctx context.Context, request *types.
 }
 
 // ConstructionDerive Derive returns the AccountIdentifier associated with a public key.
-func (on OnlineNetwork) ConstructionDerive(_ context.Context, request *types.ConstructionDeriveRequest) (*types.ConstructionDeriveResponse, *types.Error) {
-	account, err := on.client.AccountIdentifierFromPublicKey(request.PublicKey)
+func (on OnlineNetwork) ConstructionDerive(ctx context.Context, request *types.ConstructionDeriveRequest) (*types.ConstructionDeriveResponse, *types.Error) {
+	account, err := on.client.AccountIdentifierFromPublicKey(ctx, request.PublicKey)
 	if err != nil {
 		return nil, errors.ToRosetta(err)
 	}
@@ -101,11 +101,8 @@ func (on OnlineNetwork) ConstructionMetadata(ctx context.Context, request *types
 		gas := sdkmath.NewIntFromUint64(uint64(gasLimit))
 
 		suggestedFee := types.Amount{
-			Value: strconv.FormatInt(price.Amount.MulInt64(gas.Int64()).Ceil().TruncateInt64(), 10),
-			Currency: &(types.Currency{
-				Symbol:   price.Denom,
-				Decimals: 0,
-			}),
+			Value:    strconv.FormatInt(price.Amount.MulInt64(gas.Int64()).Ceil().TruncateInt64(), 10),
+			Currency: on.client.Currency(price.Denom),
 		}
 		response.SuggestedFee = []*types.Amount{&suggestedFee}
 	}
@@ -122,7 +119,7 @@ func (on OnlineNetwork) ConstructionParse(ctx context.Context, request *types.Co
 		err := errors.WrapError(errors.ErrInvalidTransaction, err.Error())
 		return nil, errors.ToRosetta(err)
 	}
-	ops, signers, err := on.client.TxOperationsAndSignersAccountIdentifiers(request.Signed, txBytes)
+	ops, signers, err := on.client.TxOperationsAndSignersAccountIdentifiers(ctx, request.Signed, txBytes)
 	if err != nil {
 		return nil, errors.ToRosetta(err)
 	}
@@ -164,7 +161,7 @@ func (on OnlineNetwork) ConstructionSubmit(ctx context.Context, request *types.C
 		return nil, errors.ToRosetta(err)
 	}
 
-	res, meta, err := on.client.PostTx(txBytes)
+	res, meta, err := on.client.PostTx(ctx, txBytes)
 	if err != nil {
 		return nil, errors.ToRosetta(err)
 	}
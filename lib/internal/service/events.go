@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+
+	"github.com/cosmos/rosetta/lib/errors"
+)
+
+// EventsBlocks returns the block_added events of the stream from the requested offset
+func (on OnlineNetwork) EventsBlocks(ctx context.Context, request *types.EventsBlocksRequest) (*types.EventsBlocksResponse, *types.Error) {
+	maxSequence, events, err := on.client.BlockEvents(ctx, request.Offset, request.Limit)
+	if err != nil {
+		return nil, errors.ToRosetta(err)
+	}
+
+	return &types.EventsBlocksResponse{
+		MaxSequence: maxSequence,
+		Events:      events,
+	}, nil
+}
@@ -50,6 +50,18 @@ func (o OfflineNetwork) MempoolTransaction(_ context.Context, _ *types.MempoolTr
 	return nil, crgerrs.ToRosetta(crgerrs.ErrOffline)
 }
 
+func (o OfflineNetwork) Call(_ context.Context, _ *types.CallRequest) (*types.CallResponse, *types.Error) {
+	return nil, crgerrs.ToRosetta(crgerrs.ErrOffline)
+}
+
+func (o OfflineNetwork) EventsBlocks(_ context.Context, _ *types.EventsBlocksRequest) (*types.EventsBlocksResponse, *types.Error) {
+	return nil, crgerrs.ToRosetta(crgerrs.ErrOffline)
+}
+
+func (o OfflineNetwork) SearchTransactions(_ context.Context, _ *types.SearchTransactionsRequest) (*types.SearchTransactionsResponse, *types.Error) {
+	return nil, crgerrs.ToRosetta(crgerrs.ErrOffline)
+}
+
 func (o OfflineNetwork) NetworkStatus(_ context.Context, _ *types.NetworkRequest) (*types.NetworkStatusResponse, *types.Error) {
 	return nil, crgerrs.ToRosetta(crgerrs.ErrOffline)
 }
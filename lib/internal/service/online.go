@@ -61,6 +61,8 @@ func networkOptionsFromClient(client crgtypes.Client, genesisBlock *types.BlockI
 			Errors:                  crgerrs.SealAndListErrors(),
 			HistoricalBalanceLookup: true,
 			TimestampStartIndex:     tsi,
+			BalanceExemptions:       client.BalanceExemptions(),
+			CallMethods:             client.CallMethods(),
 		},
 	}
 }
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+// CircuitBreaker reports whether the requests which need the node of a network must be
+// rejected, the circuit is open while the node is known to be unavailable
+type CircuitBreaker interface {
+	Open() bool
+}
+
+// NewNetworkRouter builds an API which serves multiple networks at once, each request
+// is forwarded to the API of the network matching the request network identifier.
+// networks and apis must have the same length, apis[i] serves networks[i].
+// breakers is optional, if provided breakers[i] guards the node of networks[i].
+func NewNetworkRouter(networks []*types.NetworkIdentifier, apis []crgtypes.API, breakers []CircuitBreaker) (crgtypes.API, error) {
+	if len(networks) != len(apis) {
+		return nil, fmt.Errorf("got %d networks and %d apis", len(networks), len(apis))
+	}
+	if breakers != nil && len(breakers) != len(networks) {
+		return nil, fmt.Errorf("got %d networks and %d circuit breakers", len(networks), len(breakers))
+	}
+	if len(networks) == 0 {
+		return nil, fmt.Errorf("no networks provided")
+	}
+
+	routes := make(map[string]route, len(networks))
+	for i, network := range networks {
+		key := networkKey(network)
+		if _, exists := routes[key]; exists {
+			return nil, fmt.Errorf("duplicate network %s", key)
+		}
+		rt := route{api: apis[i]}
+		if breakers != nil {
+			rt.breaker = breakers[i]
+		}
+		routes[key] = rt
+	}
+
+	return NetworkRouter{
+		networks: networks,
+		routes:   routes,
+	}, nil
+}
+
+// route is the API serving a network, and the circuit breaker guarding its node
+type route struct {
+	api     crgtypes.API
+	breaker CircuitBreaker
+}
+
+// NetworkRouter routes the requests to the API serving the requested network,
+// every request is traced in its own span
+type NetworkRouter struct {
+	networks []*types.NetworkIdentifier
+	routes   map[string]route
+}
+
+// networkKey returns the key used to route requests for the given network
+func networkKey(network *types.NetworkIdentifier) string {
+	key := network.Blockchain + "/" + network.Network
+	if network.SubNetworkIdentifier != nil {
+		key += "/" + network.SubNetworkIdentifier.Network
+	}
+	return key
+}
+
+// route returns the API serving the given network
+func (r NetworkRouter) route(network *types.NetworkIdentifier) (crgtypes.API, *types.Error) {
+	if network == nil {
+		return nil, crgerrs.ToRosetta(crgerrs.WrapError(crgerrs.ErrBadArgument, "network identifier not provided"))
+	}
+	rt, ok := r.routes[networkKey(network)]
+	if !ok {
+		return nil, crgerrs.ToRosetta(crgerrs.WrapError(crgerrs.ErrNetworkNotSupported, networkKey(network)))
+	}
+	return rt.api, nil
+}
+
+// routeOnline returns the API serving the given network for a request which needs the node,
+// the request is rejected with a retriable error while the node circuit is open
+func (r NetworkRouter) routeOnline(network *types.NetworkIdentifier) (crgtypes.API, *types.Error) {
+	api, rosErr := r.route(network)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	if rt := r.routes[networkKey(network)]; rt.breaker != nil && rt.breaker.Open() {
+		return nil, crgerrs.ToRosetta(crgerrs.WrapError(crgerrs.ErrBadGateway, fmt.Sprintf("node of network %s is unavailable", networkKey(network))))
+	}
+	return api, nil
+}
+
+// NetworkList returns all the networks served
+func (r NetworkRouter) NetworkList(ctx context.Context, _ *types.MetadataRequest) (*types.NetworkListResponse, *types.Error) {
+	_, span := startSpan(ctx, "NetworkList", nil)
+	defer span.End()
+
+	return &types.NetworkListResponse{NetworkIdentifiers: r.networks}, nil
+}
+
+func (r NetworkRouter) NetworkOptions(ctx context.Context, request *types.NetworkRequest) (_ *types.NetworkOptionsResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "NetworkOptions", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.route(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.NetworkOptions(ctx, request)
+}
+
+func (r NetworkRouter) NetworkStatus(ctx context.Context, request *types.NetworkRequest) (_ *types.NetworkStatusResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "NetworkStatus", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.NetworkStatus(ctx, request)
+}
+
+func (r NetworkRouter) AccountBalance(ctx context.Context, request *types.AccountBalanceRequest) (_ *types.AccountBalanceResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "AccountBalance", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.AccountBalance(ctx, request)
+}
+
+func (r NetworkRouter) AccountCoins(ctx context.Context, request *types.AccountCoinsRequest) (_ *types.AccountCoinsResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "AccountCoins", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.AccountCoins(ctx, request)
+}
+
+func (r NetworkRouter) Block(ctx context.Context, request *types.BlockRequest) (_ *types.BlockResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "Block", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.Block(ctx, request)
+}
+
+func (r NetworkRouter) BlockTransaction(ctx context.Context, request *types.BlockTransactionRequest) (_ *types.BlockTransactionResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "BlockTransaction", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.BlockTransaction(ctx, request)
+}
+
+func (r NetworkRouter) Mempool(ctx context.Context, request *types.NetworkRequest) (_ *types.MempoolResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "Mempool", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.Mempool(ctx, request)
+}
+
+func (r NetworkRouter) MempoolTransaction(ctx context.Context, request *types.MempoolTransactionRequest) (_ *types.MempoolTransactionResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "MempoolTransaction", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.MempoolTransaction(ctx, request)
+}
+
+func (r NetworkRouter) Call(ctx context.Context, request *types.CallRequest) (_ *types.CallResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "Call", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.Call(ctx, request)
+}
+
+func (r NetworkRouter) EventsBlocks(ctx context.Context, request *types.EventsBlocksRequest) (_ *types.EventsBlocksResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "EventsBlocks", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.EventsBlocks(ctx, request)
+}
+
+func (r NetworkRouter) SearchTransactions(ctx context.Context, request *types.SearchTransactionsRequest) (_ *types.SearchTransactionsResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "SearchTransactions", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.SearchTransactions(ctx, request)
+}
+
+func (r NetworkRouter) ConstructionCombine(ctx context.Context, request *types.ConstructionCombineRequest) (_ *types.ConstructionCombineResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "ConstructionCombine", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.route(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.ConstructionCombine(ctx, request)
+}
+
+func (r NetworkRouter) ConstructionDerive(ctx context.Context, request *types.ConstructionDeriveRequest) (_ *types.ConstructionDeriveResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "ConstructionDerive", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.route(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.ConstructionDerive(ctx, request)
+}
+
+func (r NetworkRouter) ConstructionHash(ctx context.Context, request *types.ConstructionHashRequest) (_ *types.TransactionIdentifierResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "ConstructionHash", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.route(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.ConstructionHash(ctx, request)
+}
+
+func (r NetworkRouter) ConstructionMetadata(ctx context.Context, request *types.ConstructionMetadataRequest) (_ *types.ConstructionMetadataResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "ConstructionMetadata", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.ConstructionMetadata(ctx, request)
+}
+
+func (r NetworkRouter) ConstructionParse(ctx context.Context, request *types.ConstructionParseRequest) (_ *types.ConstructionParseResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "ConstructionParse", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.route(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.ConstructionParse(ctx, request)
+}
+
+func (r NetworkRouter) ConstructionPayloads(ctx context.Context, request *types.ConstructionPayloadsRequest) (_ *types.ConstructionPayloadsResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "ConstructionPayloads", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.route(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.ConstructionPayloads(ctx, request)
+}
+
+func (r NetworkRouter) ConstructionPreprocess(ctx context.Context, request *types.ConstructionPreprocessRequest) (_ *types.ConstructionPreprocessResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "ConstructionPreprocess", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.route(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.ConstructionPreprocess(ctx, request)
+}
+
+func (r NetworkRouter) ConstructionSubmit(ctx context.Context, request *types.ConstructionSubmitRequest) (_ *types.TransactionIdentifierResponse, rosErr *types.Error) {
+	ctx, span := startSpan(ctx, "ConstructionSubmit", request.NetworkIdentifier)
+	defer func() { endSpan(span, rosErr) }()
+
+	api, rosErr := r.routeOnline(request.NetworkIdentifier)
+	if rosErr != nil {
+		return nil, rosErr
+	}
+	return api.ConstructionSubmit(ctx, request)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/require"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+// networkAPI serves the status and the options of a single network
+type networkAPI struct {
+	crgtypes.API
+	network string
+}
+
+func (a networkAPI) NetworkStatus(context.Context, *types.NetworkRequest) (*types.NetworkStatusResponse, *types.Error) {
+	return &types.NetworkStatusResponse{CurrentBlockIdentifier: &types.BlockIdentifier{Hash: a.network}}, nil
+}
+
+func (a networkAPI) NetworkOptions(context.Context, *types.NetworkRequest) (*types.NetworkOptionsResponse, *types.Error) {
+	return &types.NetworkOptionsResponse{Version: &types.Version{NodeVersion: a.network}}, nil
+}
+
+// circuitBreaker is open when open is set
+type circuitBreaker struct {
+	open atomic.Bool
+}
+
+func (b *circuitBreaker) Open() bool {
+	return b.open.Load()
+}
+
+func TestNetworkRouter(t *testing.T) {
+	cosmos := &types.NetworkIdentifier{Blockchain: "cosmos", Network: "cosmoshub-4"}
+	osmosis := &types.NetworkIdentifier{Blockchain: "cosmos", Network: "osmosis-1"}
+	breakers := []CircuitBreaker{new(circuitBreaker), new(circuitBreaker)}
+	router, err := NewNetworkRouter(
+		[]*types.NetworkIdentifier{cosmos, osmosis},
+		[]crgtypes.API{networkAPI{network: "cosmoshub-4"}, networkAPI{network: "osmosis-1"}},
+		breakers,
+	)
+	require.NoError(t, err)
+
+	list, rosErr := router.NetworkList(context.Background(), &types.MetadataRequest{})
+	require.Nil(t, rosErr)
+	require.Equal(t, []*types.NetworkIdentifier{cosmos, osmosis}, list.NetworkIdentifiers)
+
+	// the requests are routed by their network identifier
+	status, rosErr := router.NetworkStatus(context.Background(), &types.NetworkRequest{NetworkIdentifier: osmosis})
+	require.Nil(t, rosErr)
+	require.Equal(t, "osmosis-1", status.CurrentBlockIdentifier.Hash)
+	status, rosErr = router.NetworkStatus(context.Background(), &types.NetworkRequest{NetworkIdentifier: cosmos})
+	require.Nil(t, rosErr)
+	require.Equal(t, "cosmoshub-4", status.CurrentBlockIdentifier.Hash)
+
+	// the unknown networks are not supported
+	_, rosErr = router.NetworkStatus(context.Background(), &types.NetworkRequest{
+		NetworkIdentifier: &types.NetworkIdentifier{Blockchain: "cosmos", Network: "juno-1"},
+	})
+	require.Equal(t, crgerrs.ToRosetta(crgerrs.ErrNetworkNotSupported).Code, rosErr.Code)
+	_, rosErr = router.NetworkStatus(context.Background(), &types.NetworkRequest{})
+	require.Equal(t, crgerrs.ToRosetta(crgerrs.ErrBadArgument).Code, rosErr.Code)
+
+	// the requests which need the node are rejected while its circuit is open,
+	// the other networks and the requests which do not need the node are served
+	breakers[1].(*circuitBreaker).open.Store(true)
+	_, rosErr = router.NetworkStatus(context.Background(), &types.NetworkRequest{NetworkIdentifier: osmosis})
+	require.Equal(t, crgerrs.ToRosetta(crgerrs.ErrBadGateway).Code, rosErr.Code)
+	require.True(t, rosErr.Retriable)
+	_, rosErr = router.NetworkStatus(context.Background(), &types.NetworkRequest{NetworkIdentifier: cosmos})
+	require.Nil(t, rosErr)
+	options, rosErr := router.NetworkOptions(context.Background(), &types.NetworkRequest{NetworkIdentifier: osmosis})
+	require.Nil(t, rosErr)
+	require.Equal(t, "osmosis-1", options.Version.NodeVersion)
+
+	breakers[1].(*circuitBreaker).open.Store(false)
+	_, rosErr = router.NetworkStatus(context.Background(), &types.NetworkRequest{NetworkIdentifier: osmosis})
+	require.Nil(t, rosErr)
+}
+
+func TestNewNetworkRouter_invalid(t *testing.T) {
+	cosmos := &types.NetworkIdentifier{Blockchain: "cosmos", Network: "cosmoshub-4"}
+	api := networkAPI{network: "cosmoshub-4"}
+
+	// the networks are served once
+	_, err := NewNetworkRouter([]*types.NetworkIdentifier{cosmos, {Blockchain: "cosmos", Network: "cosmoshub-4"}}, []crgtypes.API{api, api}, nil)
+	require.ErrorContains(t, err, "duplicate network cosmos/cosmoshub-4")
+
+	_, err = NewNetworkRouter([]*types.NetworkIdentifier{cosmos}, []crgtypes.API{api, api}, nil)
+	require.Error(t, err)
+	_, err = NewNetworkRouter([]*types.NetworkIdentifier{cosmos}, []crgtypes.API{api}, []CircuitBreaker{nil, nil})
+	require.Error(t, err)
+	_, err = NewNetworkRouter(nil, nil, nil)
+	require.Error(t, err)
+}
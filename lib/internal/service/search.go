@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+
+	"github.com/cosmos/rosetta/lib/errors"
+)
+
+// SearchTransactions searches the transactions matching the request conditions
+func (on OnlineNetwork) SearchTransactions(ctx context.Context, request *types.SearchTransactionsRequest) (*types.SearchTransactionsResponse, *types.Error) {
+	res, err := on.client.SearchTransactions(ctx, request)
+	if err != nil {
+		return nil, errors.ToRosetta(err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer opens a span for each rosetta request, the client calls made
+// while serving the request are traced as children of this span
+var tracer = otel.Tracer("github.com/cosmos/rosetta/lib/internal/service")
+
+// startSpan opens the span of a rosetta request made for the given network
+func startSpan(ctx context.Context, name string, network *types.NetworkIdentifier) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "rosetta."+name, trace.WithSpanKind(trace.SpanKindServer))
+	if network != nil {
+		span.SetAttributes(
+			attribute.String("rosetta.blockchain", network.Blockchain),
+			attribute.String("rosetta.network", network.Network),
+		)
+	}
+	return ctx, span
+}
+
+// endSpan records the rosetta error, if any, and closes the span
+func endSpan(span trace.Span, rosErr *types.Error) {
+	if rosErr != nil {
+		span.SetAttributes(attribute.Int("rosetta.error.code", int(rosErr.Code)))
+		span.SetStatus(codes.Error, rosErr.Message)
+	}
+	span.End()
+}
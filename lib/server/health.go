@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+
+	"cosmossdk.io/log"
+
+	"github.com/cosmos/rosetta/lib/internal/service"
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+const (
+	// DefaultHealthCheckInterval is the default time between two node health checks
+	DefaultHealthCheckInterval = 10 * time.Second
+	// readyComponent is the component reported for clients not implementing crgtypes.HealthChecker
+	readyComponent = "node"
+	// livenessChecks is the number of check intervals after which
+	// the health checker is considered stuck and rosetta not alive
+	livenessChecks = 3
+)
+
+// componentHealth is the health of a node component
+type componentHealth struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// networkHealth is the health of the node components of a network
+type networkHealth struct {
+	Network    *types.NetworkIdentifier   `json:"network_identifier"`
+	Components map[string]componentHealth `json:"components"`
+}
+
+// healthReport is the result of a health check
+type healthReport struct {
+	Ready     bool            `json:"ready"`
+	CheckedAt time.Time       `json:"checked_at"`
+	Networks  []networkHealth `json:"networks"`
+}
+
+// healthChecker periodically checks the health of the nodes of the served
+// networks and caches the result, so that probes never hit the nodes directly.
+// It also supervises the nodes, opening their circuit when they are unavailable.
+type healthChecker struct {
+	networks []Network
+	interval time.Duration
+	// offline checkers do not query the nodes and are always ready
+	offline bool
+	// breakers guard the node of each network, nil if offline
+	breakers []*circuitBreaker
+	logger   log.Logger
+
+	mu      sync.RWMutex
+	report  healthReport
+	checked bool
+	// lastCheck is the time the last check completed at, it is
+	// initialized at creation to give some time to the first check
+	lastCheck time.Time
+
+	runMu sync.Mutex
+	// stopped is set by stop, run does not check the nodes anymore
+	stopped bool
+	// cancel cancels the checks of run, done is closed once run returned
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newHealthChecker(networks []Network, interval time.Duration, offline bool, breakerThreshold int, logger log.Logger) *healthChecker {
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+	var breakers []*circuitBreaker
+	if !offline {
+		breakers = make([]*circuitBreaker, len(networks))
+		for i := range networks {
+			breakers[i] = newCircuitBreaker(breakerThreshold)
+		}
+	}
+	return &healthChecker{
+		networks:  networks,
+		interval:  interval,
+		offline:   offline,
+		breakers:  breakers,
+		logger:    logger,
+		lastCheck: time.Now(),
+	}
+}
+
+// circuitBreakers returns the circuit breakers guarding the node of each network, nil if offline
+func (h *healthChecker) circuitBreakers() []service.CircuitBreaker {
+	if h.breakers == nil {
+		return nil
+	}
+	breakers := make([]service.CircuitBreaker, len(h.breakers))
+	for i, breaker := range h.breakers {
+		breakers[i] = breaker
+	}
+	return breakers
+}
+
+// run checks the nodes every interval until ctx is done or stop is called
+func (h *healthChecker) run(ctx context.Context) {
+	if h.offline {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	h.runMu.Lock()
+	if h.stopped {
+		h.runMu.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	defer close(done)
+	h.cancel, h.done = cancel, done
+	h.runMu.Unlock()
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		h.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stop stops the checks of run and waits for the check in progress to return,
+// so that the clients can be closed safely. The checks cannot be started again.
+func (h *healthChecker) stop() {
+	h.runMu.Lock()
+	h.stopped = true
+	cancel, done := h.cancel, h.done
+	h.runMu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// check checks the health of every network concurrently, each within the check
+// interval so that a hanging node does not delay the others, and caches the report
+func (h *healthChecker) check(ctx context.Context) {
+	report := healthReport{Ready: true, Networks: make([]networkHealth, len(h.networks))}
+	healthy, reachable := make([]bool, len(h.networks)), make([]bool, len(h.networks))
+	var wg sync.WaitGroup
+	for i, network := range h.networks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Networks[i], healthy[i], reachable[i] = h.checkNetwork(ctx, network)
+		}()
+	}
+	wg.Wait()
+	for i := range h.networks {
+		report.Ready = report.Ready && healthy[i]
+		h.supervise(i, reachable[i])
+	}
+	report.CheckedAt = time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.report = report
+	h.checked = true
+	h.lastCheck = report.CheckedAt
+}
+
+// checkNetwork returns the health of the node components of a network, a node
+// which is catching up is not healthy but it is reachable
+func (h *healthChecker) checkNetwork(ctx context.Context, network Network) (health networkHealth, healthy, reachable bool) {
+	ctx, cancel := context.WithTimeout(ctx, h.interval)
+	defer cancel()
+
+	health = networkHealth{
+		Network:    network.Identifier,
+		Components: make(map[string]componentHealth),
+	}
+	healthy, reachable = true, true
+	for component, err := range clientHealth(ctx, network.Client) {
+		if err != nil {
+			healthy = false
+			reachable = reachable && component == crgtypes.SyncComponent
+			health.Components[component] = componentHealth{Error: err.Error()}
+			continue
+		}
+		health.Components[component] = componentHealth{Healthy: true}
+	}
+	return health, healthy, reachable
+}
+
+// clientHealth returns the health of the client node components
+func clientHealth(ctx context.Context, client crgtypes.Client) map[string]error {
+	if checker, ok := client.(crgtypes.HealthChecker); ok {
+		return checker.Health(ctx)
+	}
+	return map[string]error{readyComponent: client.Ready()}
+}
+
+// alive reports whether the health checker is still running
+func (h *healthChecker) alive() bool {
+	if h.offline {
+		return true
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return time.Since(h.lastCheck) < livenessChecks*h.interval
+}
+
+// ready returns the last health report, the report is not ready if no check was completed yet
+func (h *healthChecker) ready() healthReport {
+	if h.offline {
+		return healthReport{Ready: true}
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if !h.checked {
+		return healthReport{}
+	}
+	return h.report
+}
+
+// livenessHandler serves the liveness probe, rosetta is alive as long as the nodes
+// are being checked. The node health is not considered as restarting rosetta does not fix the node.
+func (h *healthChecker) livenessHandler(w http.ResponseWriter, _ *http.Request) {
+	if !h.alive() {
+		writeHealth(w, http.StatusServiceUnavailable, map[string]string{"status": "health checks are not running"})
+		return
+	}
+	writeHealth(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readinessHandler serves the readiness probe with the cached health report,
+// rosetta is ready when all the node components of all the networks are healthy
+func (h *healthChecker) readinessHandler(w http.ResponseWriter, _ *http.Request) {
+	report := h.ready()
+	if !report.Ready {
+		writeHealth(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	writeHealth(w, http.StatusOK, report)
+}
+
+func writeHealth(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
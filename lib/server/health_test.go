@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/require"
+
+	"cosmossdk.io/log"
+
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+// readyClient reports the node health through Ready only
+type readyClient struct {
+	crgtypes.Client
+	err error
+}
+
+func (c readyClient) Ready() error { return c.err }
+
+// componentsClient reports the health of each node component
+type componentsClient struct {
+	crgtypes.Client
+	health map[string]error
+}
+
+func (c componentsClient) Health(context.Context) map[string]error { return c.health }
+
+func TestHealthChecker(t *testing.T) {
+	network := &types.NetworkIdentifier{Blockchain: "app", Network: "network"}
+	client := &componentsClient{health: map[string]error{"cometbft": nil, "sync": errors.New("node is syncing")}}
+	checker := newHealthChecker([]Network{
+		{Identifier: network, Client: client},
+		{Identifier: &types.NetworkIdentifier{Blockchain: "app", Network: "other"}, Client: readyClient{}},
+	}, time.Minute, false, 1, log.NewNopLogger())
+
+	probe := func(handler http.HandlerFunc) (int, healthReport) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		var report healthReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		return rec.Code, report
+	}
+
+	// not ready until the nodes are checked
+	code, _ := probe(checker.readinessHandler)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	checker.check(context.Background())
+	code, report := probe(checker.readinessHandler)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Len(t, report.Networks, 2)
+	require.Equal(t, componentHealth{Healthy: true}, report.Networks[0].Components["cometbft"])
+	require.Equal(t, componentHealth{Error: "node is syncing"}, report.Networks[0].Components["sync"])
+	require.Equal(t, componentHealth{Healthy: true}, report.Networks[1].Components[readyComponent])
+
+	// the node is synced, the cached result changes only after the next check
+	client.health["sync"] = nil
+	code, _ = probe(checker.readinessHandler)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	checker.check(context.Background())
+	code, report = probe(checker.readinessHandler)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, report.Ready)
+
+	// liveness does not depend on the node health
+	rec := httptest.NewRecorder()
+	checker.livenessHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// the checks stopped running
+	checker.lastCheck = time.Now().Add(-livenessChecks * time.Minute)
+	rec = httptest.NewRecorder()
+	checker.livenessHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+// blockingClient reports its health once ctx is done
+type blockingClient struct {
+	crgtypes.Client
+	checking chan struct{}
+	checked  atomic.Bool
+}
+
+func (c *blockingClient) Health(ctx context.Context) map[string]error {
+	close(c.checking)
+	<-ctx.Done()
+	c.checked.Store(true)
+	return map[string]error{readyComponent: ctx.Err()}
+}
+
+func TestHealthChecker_stop(t *testing.T) {
+	client := &blockingClient{checking: make(chan struct{})}
+	checker := newHealthChecker([]Network{
+		{Identifier: &types.NetworkIdentifier{Blockchain: "app", Network: "network"}, Client: client},
+	}, time.Minute, false, 1, log.NewNopLogger())
+
+	stopped := make(chan struct{})
+	go func() {
+		checker.run(context.Background())
+		close(stopped)
+	}()
+	<-client.checking
+
+	// stop cancels the check in progress and waits for it
+	checker.stop()
+	require.True(t, client.checked.Load())
+	<-stopped
+
+	// the checks do not start once stopped
+	checker.run(context.Background())
+}
+
+// concurrentClient reports its health while the blocking client is checked
+type concurrentClient struct {
+	crgtypes.Client
+	blocking *blockingClient
+}
+
+func (c concurrentClient) Health(ctx context.Context) map[string]error {
+	<-c.blocking.checking
+	return map[string]error{readyComponent: ctx.Err()}
+}
+
+func TestHealthChecker_hangingNode(t *testing.T) {
+	blocking := &blockingClient{checking: make(chan struct{})}
+	checker := newHealthChecker([]Network{
+		{Identifier: &types.NetworkIdentifier{Blockchain: "app", Network: "hanging"}, Client: blocking},
+		{Identifier: &types.NetworkIdentifier{Blockchain: "app", Network: "network"}, Client: concurrentClient{blocking: blocking}},
+	}, 50*time.Millisecond, false, 1, log.NewNopLogger())
+
+	// the networks are checked concurrently, each within its own timeout
+	checker.check(context.Background())
+	report := checker.ready()
+	require.False(t, report.Ready)
+	require.Equal(t, componentHealth{Error: context.DeadlineExceeded.Error()}, report.Networks[0].Components[readyComponent])
+	require.Equal(t, componentHealth{Healthy: true}, report.Networks[1].Components[readyComponent])
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	metricsNamespace = "rosetta"
+	// unknownLabel labels the requests to paths which are not rosetta routes, which keeps
+	// the cardinality of the endpoint label bounded, and errors without a rosetta code
+	unknownLabel = "unknown"
+	// maxErrorBodySize is the maximum size of an error response read to extract the rosetta error code
+	maxErrorBodySize = 64 * 1024
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of rosetta API requests by endpoint and http status code.",
+	}, []string{"endpoint", "code"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the rosetta API requests by endpoint.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"endpoint"})
+
+	httpErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "http",
+		Name:      "errors_total",
+		Help:      "Number of rosetta errors returned by endpoint and rosetta error code.",
+	}, []string{"endpoint", "error_code"})
+)
+
+// instrument wraps the handler recording the number of requests, their latency
+// and the rosetta error codes returned for each of the given endpoints.
+func instrument(next http.Handler, endpoints map[string]struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := r.URL.Path
+		if _, ok := endpoints[endpoint]; !ok {
+			endpoint = unknownLabel
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		httpRequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+		httpRequests.WithLabelValues(endpoint, strconv.Itoa(rec.status)).Inc()
+		if rec.status != http.StatusOK {
+			httpErrors.WithLabelValues(endpoint, rec.errorCode()).Inc()
+		}
+	})
+}
+
+// statusRecorder records the status code of a response, and its body
+// if the response is an error, to extract the rosetta error code
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	errBody     bytes.Buffer
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	if r.status != http.StatusOK && r.errBody.Len() < maxErrorBodySize {
+		r.errBody.Write(b)
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// errorCode returns the code of the rosetta error written in the response body
+func (r *statusRecorder) errorCode() string {
+	var rosErr struct {
+		Code *int32 `json:"code"`
+	}
+	if err := json.Unmarshal(r.errBody.Bytes(), &rosErr); err != nil || rosErr.Code == nil {
+		return unknownLabel
+	}
+	return strconv.FormatInt(int64(*rosErr.Code), 10)
+}
+
+// newMetricsServer builds the http server exposing the prometheus metrics
+func newMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/rosetta-sdk-go/server"
+)
+
+func TestInstrument(t *testing.T) {
+	handler := instrument(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/block" {
+			server.EncodeJSONResponse(&types.Error{Code: 404, Message: "not found"}, http.StatusInternalServerError, w)
+			return
+		}
+		server.EncodeJSONResponse(&types.NetworkListResponse{}, http.StatusOK, w)
+	}), map[string]struct{}{"/block": {}, "/network/list": {}})
+
+	for _, path := range []string{"/network/list", "/block", "/not-a-route"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	require.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues("/network/list", "200")))
+	require.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues("/block", "500")))
+	require.Equal(t, float64(1), testutil.ToFloat64(httpErrors.WithLabelValues("/block", "404")))
+	require.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues(unknownLabel, "200")))
+}
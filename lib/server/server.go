@@ -1,12 +1,16 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"cosmossdk.io/log"
 
@@ -17,8 +21,11 @@ import (
 )
 
 const (
-	DefaultRetries   = 5
-	DefaultRetryWait = 5 * time.Second
+	DefaultRetries         = 5
+	DefaultRetryWait       = 5 * time.Second
+	DefaultShutdownTimeout = 30 * time.Second
+	// readHeaderTimeout bounds the time allowed to read request headers
+	readHeaderTimeout = 10 * time.Second
 )
 
 // Settings define the rosetta server settings
@@ -36,25 +43,195 @@ type Settings struct {
 	Retries int
 	// RetryWait is the time that will be waited between retries
 	RetryWait time.Duration
+	// ShutdownTimeout is the maximum time given to in-flight requests to complete
+	// when the server is stopped through the context provided to Start
+	ShutdownTimeout time.Duration
+	// TLSCertFile is the path of the certificate used to serve HTTPS, it must be set along with TLSKeyFile.
+	// The certificate files are reloaded when they change on disk.
+	TLSCertFile string
+	// TLSKeyFile is the path of the private key matching TLSCertFile
+	TLSKeyFile string
+	// TLSClientCAFile is the path of the CA bundle used to verify client certificates,
+	// if provided clients are required to present a valid certificate (mutual TLS).
+	// The /healthz and /readyz probes are served without a client certificate.
+	TLSClientCAFile string
+	// MetricsListen is the address the prometheus metrics will be served at,
+	// metrics are not served if it is empty
+	MetricsListen string
+	// AdditionalNetworks defines other networks served along with Network, each one
+	// with its own client. Requests are routed by their network identifier.
+	AdditionalNetworks []Network
+	// HealthCheckInterval is the time between two checks of the nodes health,
+	// the results are cached and served at /healthz and /readyz
+	HealthCheckInterval time.Duration
+	// TracingEndpoint is the OTLP gRPC endpoint the traces are exported to,
+	// tracing is disabled if it is empty
+	TracingEndpoint string
+	// TracingInsecure disables TLS when connecting to TracingEndpoint
+	TracingInsecure bool
+	// TracingSampleRatio is the ratio of the requests which are traced, between 0 and 1, see
+	// DefaultTracingSampleRatio. With 0 only the requests propagating a sampled trace are traced.
+	TracingSampleRatio float64
+	// CircuitBreakerThreshold is the number of consecutive failed health checks after which
+	// the requests to a node are rejected with a retriable error, and rosetta reconnects to it
+	CircuitBreakerThreshold int
+}
+
+// Network defines a network served by the rosetta server
+type Network struct {
+	// Identifier identifies the network
+	Identifier *types.NetworkIdentifier
+	// Client is the API handler of the network
+	Client crgtypes.Client
+}
+
+// networks returns all the networks to serve, starting from the main one
+func (s Settings) networks() []Network {
+	networks := make([]Network, 0, 1+len(s.AdditionalNetworks))
+	networks = append(networks, Network{Identifier: s.Network, Client: s.Client})
+	return append(networks, s.AdditionalNetworks...)
 }
 
 type Server struct {
 	h      http.Handler
 	addr   string
 	logger log.Logger
+
+	srv             *http.Server
+	metricsSrv      *http.Server
+	health          *healthChecker
+	tracerProvider  *sdktrace.TracerProvider
+	clients         []crgtypes.Client
+	shutdownTimeout time.Duration
+	shutdown        *shutdown
+}
+
+// shutdown makes sure the server is shut down only once
+type shutdown struct {
+	once sync.Once
+	err  error
+}
+
+// Start serves the rosetta API and blocks until the server is stopped.
+// When ctx is cancelled the server is gracefully shut down, waiting at most
+// the configured shutdown timeout for in-flight requests to complete.
+func (h Server) Start(ctx context.Context) error {
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	defer stopHealth()
+	go h.health.run(healthCtx)
+
+	errCh := make(chan error, 2)
+	if h.metricsSrv != nil {
+		go func() {
+			h.logger.Info(fmt.Sprintf("Rosetta metrics listening on add %s", h.metricsSrv.Addr))
+			errCh <- h.metricsSrv.ListenAndServe()
+		}()
+	}
+	go func() {
+		if h.srv.TLSConfig != nil {
+			h.logger.Info(fmt.Sprintf("Rosetta server listening with TLS on add %s", h.addr))
+			// certificates are provided by the tls config
+			errCh <- h.srv.ListenAndServeTLS("", "")
+			return
+		}
+		h.logger.Info(fmt.Sprintf("Rosetta server listening on add %s", h.addr))
+		errCh <- h.srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		// the listener failed, release the node connections anyway
+		return errors.Join(err, h.Shutdown(context.Background()))
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), h.shutdownTimeout)
+	defer cancel()
+	return h.Shutdown(shutdownCtx)
+}
+
+// Shutdown stops accepting new connections, waits for in-flight requests to
+// complete or for ctx to expire, stops the health checks and closes the connections
+// to the node. Calling Shutdown more than once returns the result of the first call.
+func (h Server) Shutdown(ctx context.Context) error {
+	h.shutdown.once.Do(func() {
+		h.logger.Info("Rosetta server shutting down")
+		err := h.srv.Shutdown(ctx)
+		if err != nil {
+			err = fmt.Errorf("draining in-flight requests: %w", err)
+		}
+		if h.metricsSrv != nil {
+			if metricsErr := h.metricsSrv.Shutdown(ctx); metricsErr != nil {
+				err = errors.Join(err, fmt.Errorf("stopping metrics server: %w", metricsErr))
+			}
+		}
+		// the health checks use the clients, they must be stopped before closing them
+		h.health.stop()
+		err = errors.Join(err, h.closeClients())
+		if h.tracerProvider != nil {
+			// flush the spans which were not exported yet
+			if tracingErr := h.tracerProvider.Shutdown(ctx); tracingErr != nil {
+				err = errors.Join(err, fmt.Errorf("stopping tracer provider: %w", tracingErr))
+			}
+		}
+		h.shutdown.err = err
+	})
+	return h.shutdown.err
 }
 
-func (h Server) Start() error {
-	h.logger.Info(fmt.Sprintf("Rosetta server listening on add %s", h.addr))
-	return http.ListenAndServe(h.addr, h.h) //nolint:gosec // users are recommended to operate a proxy in front of this server
+func (h Server) closeClients() error {
+	var err error
+	for _, client := range h.clients {
+		if closeErr := client.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("closing client: %w", closeErr))
+		}
+	}
+	return err
 }
 
-func NewServer(settings Settings) (Server, error) {
+// NewServer builds the server of the networks of the settings, bootstrapping their clients if
+// online. The clients are closed if the server cannot be built.
+func NewServer(settings Settings) (srv Server, err error) {
+	logger := log.NewLogger(os.Stdout).With(log.ModuleKey, "rosetta")
+
+	networks := settings.networks()
+	identifiers := make([]*types.NetworkIdentifier, len(networks))
+	clients := make([]crgtypes.Client, len(networks))
+	var tracerProvider *sdktrace.TracerProvider
+	// release the clients and the tracer provider unless the server is built
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, client := range clients {
+			if client == nil {
+				continue
+			}
+			if closeErr := client.Close(); closeErr != nil {
+				logger.Error("[Rosetta]- Failed to close client", "error", closeErr)
+			}
+		}
+		if tracerProvider != nil {
+			_ = tracerProvider.Shutdown(context.Background())
+		}
+	}()
+
+	for i, network := range networks {
+		if network.Client == nil {
+			return Server{}, fmt.Errorf("client is nil")
+		}
+		identifiers[i] = network.Identifier
+		clients[i] = network.Client
+	}
+
 	asserter, err := assert.NewServer(
-		settings.Client.SupportedOperations(),
+		supportedOperations(clients),
 		true,
-		[]*types.NetworkIdentifier{settings.Network},
-		nil,
+		identifiers,
+		callMethods(clients),
 		false,
 		"",
 	)
@@ -62,44 +239,136 @@ func NewServer(settings Settings) (Server, error) {
 		return Server{}, fmt.Errorf("cannot build asserter: %w", err)
 	}
 
-	logger := log.NewLogger(os.Stdout).With(log.ModuleKey, "rosetta")
+	tlsConfig, err := newTLSConfig(settings, logger)
+	if err != nil {
+		return Server{}, fmt.Errorf("cannot build tls config: %w", err)
+	}
+
+	tracerProvider, err = newTracerProvider(settings)
+	if err != nil {
+		return Server{}, fmt.Errorf("cannot set up tracing: %w", err)
+	}
 
-	var adapter crgtypes.API
-	switch settings.Offline {
-	case true:
-		adapter, err = newOfflineAdapter(settings)
-	case false:
-		adapter, err = newOnlineAdapter(settings, logger)
+	adapters := make([]crgtypes.API, 0, len(networks))
+	for _, network := range networks {
+		var adapter crgtypes.API
+		switch settings.Offline {
+		case true:
+			adapter, err = newOfflineAdapter(network)
+		case false:
+			adapter, err = newOnlineAdapter(settings, network, logger)
+		}
+		if err != nil {
+			return Server{}, err
+		}
+		adapters = append(adapters, adapter)
 	}
+
+	health := newHealthChecker(networks, settings.HealthCheckInterval, settings.Offline, settings.CircuitBreakerThreshold, logger)
+
+	adapter, err := service.NewNetworkRouter(identifiers, adapters, health.circuitBreakers())
 	if err != nil {
 		return Server{}, err
 	}
-	h := server.NewRouter(
+
+	routers := []server.Router{
 		server.NewAccountAPIController(adapter, asserter),
 		server.NewBlockAPIController(adapter, asserter),
 		server.NewNetworkAPIController(adapter, asserter),
 		server.NewMempoolAPIController(adapter, asserter),
+		server.NewSearchAPIController(adapter, asserter),
+		server.NewEventsAPIController(adapter, asserter),
+		server.NewCallAPIController(adapter, asserter),
 		server.NewConstructionAPIController(adapter, asserter),
-	)
+	}
+	endpoints := make(map[string]struct{})
+	for _, router := range routers {
+		for _, route := range router.Routes() {
+			endpoints[route.Pattern] = struct{}{}
+		}
+	}
+	h := newHandler(health, traceContext(instrument(server.NewRouter(routers...), endpoints)), settings.TLSClientCAFile != "")
+
+	var metricsSrv *http.Server
+	if settings.MetricsListen != "" {
+		metricsSrv = newMetricsServer(settings.MetricsListen)
+	}
+
+	shutdownTimeout := settings.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
 
 	return Server{
 		h:      h,
 		addr:   settings.Listen,
 		logger: logger,
+		srv: &http.Server{
+			Addr:              settings.Listen,
+			Handler:           h,
+			TLSConfig:         tlsConfig,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+		metricsSrv:      metricsSrv,
+		health:          health,
+		tracerProvider:  tracerProvider,
+		clients:         clients,
+		shutdownTimeout: shutdownTimeout,
+		shutdown:        new(shutdown),
 	}, nil
 }
 
-func newOfflineAdapter(settings Settings) (crgtypes.API, error) {
-	if settings.Client == nil {
-		return nil, fmt.Errorf("client is nil")
+// newHandler routes the probes to the health checker and the other requests to the rosetta
+// API, which requires a client certificate if clientCerts is true. The probes do not require
+// one, as the probes of orchestrators such as kubernetes cannot present a certificate.
+func newHandler(health *healthChecker, api http.Handler, clientCerts bool) http.Handler {
+	if clientCerts {
+		api = requireClientCert(api)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", health.livenessHandler)
+	mux.HandleFunc("GET /readyz", health.readinessHandler)
+	mux.Handle("/", api)
+	return mux
+}
+
+// supportedOperations returns the operations supported by all the clients, without duplicates
+func supportedOperations(clients []crgtypes.Client) []string {
+	var operations []string
+	seen := make(map[string]struct{})
+	for _, client := range clients {
+		for _, op := range client.SupportedOperations() {
+			if _, ok := seen[op]; ok {
+				continue
+			}
+			seen[op] = struct{}{}
+			operations = append(operations, op)
+		}
 	}
-	return service.NewOffline(settings.Network, settings.Client)
+	return operations
 }
 
-func newOnlineAdapter(settings Settings, logger log.Logger) (crgtypes.API, error) {
-	if settings.Client == nil {
-		return nil, fmt.Errorf("client is nil")
+// callMethods returns the methods supported by /call on any of the networks
+func callMethods(clients []crgtypes.Client) []string {
+	var methods []string
+	seen := make(map[string]struct{})
+	for _, client := range clients {
+		for _, method := range client.CallMethods() {
+			if _, ok := seen[method]; ok {
+				continue
+			}
+			seen[method] = struct{}{}
+			methods = append(methods, method)
+		}
 	}
+	return methods
+}
+
+func newOfflineAdapter(network Network) (crgtypes.API, error) {
+	return service.NewOffline(network.Identifier, network.Client)
+}
+
+func newOnlineAdapter(settings Settings, network Network, logger log.Logger) (crgtypes.API, error) {
 	if settings.Retries <= 0 {
 		settings.Retries = DefaultRetries
 	}
@@ -108,19 +377,19 @@ func newOnlineAdapter(settings Settings, logger log.Logger) (crgtypes.API, error
 	}
 
 	var err error
-	err = settings.Client.Bootstrap()
+	err = network.Client.Bootstrap()
 	if err != nil {
 		return nil, err
 	}
 
 	for i := 0; i < settings.Retries; i++ {
-		err = settings.Client.Ready()
+		err = network.Client.Ready()
 		if err != nil {
-			logger.Error("[Rosetta]- Client is not ready. Retrying ...", "error", err)
+			logger.Error("[Rosetta]- Client is not ready. Retrying ...", "network", network.Identifier.Network, "error", err)
 			time.Sleep(settings.RetryWait)
 			continue
 		}
-		return service.NewOnlineNetwork(settings.Network, settings.Client, logger)
+		return service.NewOnlineNetwork(network.Identifier, network.Client, logger)
 	}
-	return nil, fmt.Errorf("maximum number of retries exceeded, last error: %w", err)
+	return nil, fmt.Errorf("maximum number of retries exceeded for network %s, last error: %w", network.Identifier.Network, err)
 }
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/require"
+
+	"cosmossdk.io/log"
+
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+// closingClient records whether it was closed
+type closingClient struct {
+	crgtypes.Client
+	closed atomic.Bool
+}
+
+func (c *closingClient) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestServer_Start(t *testing.T) {
+	// reserve a free port for the server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+	client := new(closingClient)
+	srv := Server{
+		h:               handler,
+		addr:            addr,
+		logger:          log.NewNopLogger(),
+		srv:             &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: readHeaderTimeout},
+		health:          newHealthChecker(nil, time.Minute, true, 1, log.NewNopLogger()),
+		clients:         []crgtypes.Client{client},
+		shutdownTimeout: time.Minute,
+		shutdown:        new(shutdown),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Start(ctx) }()
+
+	// the in-flight request is served until its end
+	responses := make(chan string, 1)
+	go func() {
+		// retry until the server listens
+		for {
+			res, err := http.Get("http://" + addr) //nolint:gosec // the address is local
+			if err != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			body, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			responses <- string(body)
+			return
+		}
+	}()
+	<-started
+	cancel()
+
+	// the server drains the request before closing the clients
+	select {
+	case <-stopped:
+		t.Fatal("server stopped before the in-flight request completed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.False(t, client.closed.Load())
+
+	close(release)
+	require.Equal(t, "done", <-responses)
+	require.NoError(t, <-stopped)
+	require.True(t, client.closed.Load())
+
+	// new connections are refused
+	_, err = http.Get("http://" + addr) //nolint:gosec // the address is local
+	require.Error(t, err)
+	// shutting down again returns the result of the first shutdown
+	require.NoError(t, srv.Shutdown(context.Background()))
+}
+
+// failingClient fails to bootstrap
+type failingClient struct {
+	closingClient
+}
+
+func (*failingClient) SupportedOperations() []string { return []string{"transfer"} }
+func (*failingClient) CallMethods() []string         { return nil }
+func (*failingClient) Bootstrap() error              { return errors.New("bootstrap failed") }
+
+func TestNewServer_closesClients(t *testing.T) {
+	client := new(failingClient)
+	_, err := NewServer(Settings{
+		Network: &types.NetworkIdentifier{Blockchain: "cosmos", Network: "cosmoshub-4"},
+		Client:  client,
+		Listen:  "localhost:0",
+	})
+	require.Error(t, err)
+	require.True(t, client.closed.Load())
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+// DefaultCircuitBreakerThreshold is the default number of consecutive failed
+// health checks after which the requests to a node are rejected
+const DefaultCircuitBreakerThreshold = 3
+
+var circuitOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: metricsNamespace,
+	Subsystem: "node",
+	Name:      "circuit_open",
+	Help:      "Whether the requests to the node of a network are rejected because the node is unavailable.",
+}, []string{"network"})
+
+// circuitBreaker rejects the requests to a node after repeated failed health checks,
+// the circuit is closed again as soon as the node is healthy
+type circuitBreaker struct {
+	threshold int
+	// failures is the number of consecutive failed checks,
+	// it is only accessed by the health checker
+	failures int
+	open     atomic.Bool
+}
+
+func newCircuitBreaker(threshold int) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultCircuitBreakerThreshold
+	}
+	return &circuitBreaker{threshold: threshold}
+}
+
+// Open reports whether the requests to the node must be rejected
+func (b *circuitBreaker) Open() bool {
+	return b.open.Load()
+}
+
+// record records the result of a health check and reports whether the circuit is open
+func (b *circuitBreaker) record(healthy bool) bool {
+	if healthy {
+		b.failures = 0
+		b.open.Store(false)
+		return false
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.open.Store(true)
+	}
+	return b.open.Load()
+}
+
+// supervise updates the circuit breaker of a network with the reachability of its node.
+// While the circuit is open the client connections are replaced at every check,
+// so that rosetta recovers by itself when the node restarts.
+func (h *healthChecker) supervise(i int, reachable bool) {
+	if h.breakers == nil {
+		return
+	}
+	network, breaker := h.networks[i], h.breakers[i]
+	wasOpen := breaker.Open()
+	open := breaker.record(reachable)
+	if open {
+		circuitOpen.WithLabelValues(network.Identifier.Network).Set(1)
+	} else {
+		circuitOpen.WithLabelValues(network.Identifier.Network).Set(0)
+	}
+
+	switch {
+	case !open:
+		if wasOpen {
+			h.logger.Info("[Rosetta]- Node is available again, closing circuit", "network", network.Identifier.Network)
+		}
+		return
+	case !wasOpen:
+		h.logger.Error("[Rosetta]- Node is unavailable, opening circuit", "network", network.Identifier.Network)
+	}
+
+	reconnector, ok := network.Client.(crgtypes.Reconnector)
+	if !ok {
+		return
+	}
+	if err := reconnector.Reconnect(); err != nil {
+		h.logger.Error("[Rosetta]- Failed to reconnect to the node", "network", network.Identifier.Network, "error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/stretchr/testify/require"
+
+	"cosmossdk.io/log"
+
+	crgtypes "github.com/cosmos/rosetta/lib/types"
+)
+
+// reconnectingClient counts the reconnections made while its node is unavailable
+type reconnectingClient struct {
+	componentsClient
+	reconnects int
+}
+
+func (c *reconnectingClient) Reconnect() error {
+	c.reconnects++
+	return nil
+}
+
+func TestSupervisor(t *testing.T) {
+	client := &reconnectingClient{componentsClient: componentsClient{health: map[string]error{
+		"cometbft":             errors.New("connection refused"),
+		crgtypes.SyncComponent: nil,
+	}}}
+	checker := newHealthChecker([]Network{
+		{Identifier: &types.NetworkIdentifier{Blockchain: "app", Network: "network"}, Client: client},
+	}, time.Minute, false, 2, log.NewNopLogger())
+	breaker := checker.circuitBreakers()[0]
+
+	// the circuit opens after threshold consecutive failures
+	checker.check(context.Background())
+	require.False(t, breaker.Open())
+	require.Zero(t, client.reconnects)
+	checker.check(context.Background())
+	require.True(t, breaker.Open())
+	require.Equal(t, 1, client.reconnects)
+
+	// a syncing node is not ready but reachable, the circuit closes
+	client.health["cometbft"] = nil
+	client.health[crgtypes.SyncComponent] = errors.New("node is syncing")
+	checker.check(context.Background())
+	require.False(t, breaker.Open())
+	require.False(t, checker.ready().Ready)
+	require.Equal(t, 1, client.reconnects)
+}
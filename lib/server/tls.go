@@ -0,0 +1,203 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"cosmossdk.io/log"
+)
+
+// tlsReloadInterval defines how often the certificate files are checked for changes
+const tlsReloadInterval = 10 * time.Second
+
+// nextProtos are the protocols negotiated through ALPN. The http server only adds them to
+// the base config, so they are set on the configs returned per handshake to keep HTTP/2.
+var nextProtos = []string{"h2", "http/1.1"}
+
+// certReloader provides the TLS certificate and the client CA pool read from disk.
+// The files are checked for changes at most once every tlsReloadInterval during
+// handshakes, so rotated certificates are picked up without restarting the server.
+type certReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	logger log.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+func newCertReloader(certFile, keyFile, clientCAFile string, logger log.Logger) (*certReloader, error) {
+	r := &certReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		logger:       logger,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reads the certificate, the key and the client CA bundle from disk
+func (r *certReloader) reload() error {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading tls key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("reading client ca file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid certificates found in client ca file %s", r.clientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	r.lastCheck = time.Now()
+	return nil
+}
+
+func (r *certReloader) statFiles() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, 3)
+	for _, file := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading tls file info: %w", err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// maybeReload reloads the files if they changed since the last check,
+// on failure the previously loaded certificates are kept.
+func (r *certReloader) maybeReload() {
+	r.mu.Lock()
+	if time.Since(r.lastCheck) < tlsReloadInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCheck = time.Now()
+	previous := r.modTimes
+	r.mu.Unlock()
+
+	current, err := r.statFiles()
+	if err != nil {
+		r.logger.Error("[Rosetta]- Unable to check tls files for changes", "error", err)
+		return
+	}
+	changed := false
+	for file, modTime := range current {
+		if !modTime.Equal(previous[file]) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		r.logger.Error("[Rosetta]- Unable to reload tls certificates, keeping the previous ones", "error", err)
+		return
+	}
+	r.logger.Info("[Rosetta]- Reloaded tls certificates")
+}
+
+// getCertificate implements tls.Config.GetCertificate
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.maybeReload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// getConfigForClient implements tls.Config.GetConfigForClient, it is used
+// to serve the latest client CA pool when client verification is enabled.
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.maybeReload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tlsConfig(r.cert, r.clientCAs), nil
+}
+
+func (r *certReloader) tlsConfig(cert *tls.Certificate, clientCAs *x509.CertPool) *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+	}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{*cert}
+	}
+	if r.clientCAFile != "" {
+		// the certificates are required by requireClientCert rather than during the
+		// handshake, so that the probes can be served to clients without a certificate
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config
+}
+
+// requireClientCert rejects the requests of the clients which did not present a certificate
+// signed by the client CA, the presented certificates are verified during the handshake
+func requireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newTLSConfig builds the tls configuration of the server given the settings,
+// nil is returned if TLS is not enabled.
+func newTLSConfig(settings Settings, logger log.Logger) (*tls.Config, error) {
+	switch {
+	case settings.TLSCertFile == "" && settings.TLSKeyFile == "":
+		if settings.TLSClientCAFile != "" {
+			return nil, fmt.Errorf("client ca file provided without a tls certificate and key")
+		}
+		return nil, nil
+	case settings.TLSCertFile == "" || settings.TLSKeyFile == "":
+		return nil, fmt.Errorf("both tls certificate and key must be provided")
+	}
+
+	reloader, err := newCertReloader(settings.TLSCertFile, settings.TLSKeyFile, settings.TLSClientCAFile, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	config := reloader.tlsConfig(nil, nil)
+	config.GetCertificate = reloader.getCertificate
+	config.GetConfigForClient = reloader.getConfigForClient
+	return config, nil
+}
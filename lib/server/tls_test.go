@@ -0,0 +1,141 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"cosmossdk.io/log"
+)
+
+// writeSelfSignedCert writes a self signed certificate and its key to dir
+func writeSelfSignedCert(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile
+}
+
+func leafCommonName(t *testing.T, r *certReloader) string {
+	t.Helper()
+	cert, err := r.getCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir, "first")
+
+	r, err := newCertReloader(certFile, keyFile, certFile, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, "first", leafCommonName(t, r))
+
+	config, err := r.getConfigForClient(nil)
+	require.NoError(t, err)
+	require.NotNil(t, config.ClientCAs)
+	require.Contains(t, config.NextProtos, "h2")
+
+	// rotate the certificate, changes are not picked up before the reload interval
+	writeSelfSignedCert(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.Equal(t, "first", leafCommonName(t, r))
+
+	// once the interval elapsed the new certificate is served
+	r.lastCheck = time.Now().Add(-2 * tlsReloadInterval)
+	require.Equal(t, "second", leafCommonName(t, r))
+
+	// a broken certificate keeps the previous one
+	require.NoError(t, os.WriteFile(certFile, []byte("invalid"), 0o600))
+	future = future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	r.lastCheck = time.Now().Add(-2 * tlsReloadInterval)
+	require.Equal(t, "second", leafCommonName(t, r))
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir(), "rosetta")
+
+	config, err := newTLSConfig(Settings{}, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Nil(t, config)
+
+	_, err = newTLSConfig(Settings{TLSCertFile: certFile}, log.NewNopLogger())
+	require.Error(t, err)
+
+	_, err = newTLSConfig(Settings{TLSClientCAFile: certFile}, log.NewNopLogger())
+	require.Error(t, err)
+
+	config, err = newTLSConfig(Settings{TLSCertFile: certFile, TLSKeyFile: keyFile}, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NotNil(t, config.GetCertificate)
+}
+
+func TestNewHandler_clientCerts(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir(), "rosetta")
+	config, err := newTLSConfig(Settings{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: certFile}, log.NewNopLogger())
+	require.NoError(t, err)
+
+	api := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	health := newHealthChecker(nil, time.Minute, true, 1, log.NewNopLogger())
+	srv := httptest.NewUnstartedServer(newHandler(health, api, true))
+	srv.TLS = config
+	srv.StartTLS()
+	defer srv.Close()
+
+	get := func(path string, certs ...tls.Certificate) int {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			Certificates:       certs,
+			InsecureSkipVerify: true, //nolint:gosec // the server certificate is self signed
+		}}}
+		res, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		return res.StatusCode
+	}
+
+	// the probes are served without a client certificate
+	require.Equal(t, http.StatusOK, get("/healthz"))
+	require.Equal(t, http.StatusOK, get("/readyz"))
+	// the api requires one
+	require.Equal(t, http.StatusUnauthorized, get("/network/list"))
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, get("/network/list", cert))
+}
@@ -20,6 +20,12 @@ type NetworkInformationProvider interface {
 	OperationStatuses() []*types.OperationStatus
 	// Version returns the version of the node
 	Version() string
+	// BalanceExemptions returns the balances which can change without a corresponding operation
+	BalanceExemptions() []*types.BalanceExemption
+	// CallMethods returns the methods supported by /call
+	CallMethods() []string
+	// Currency returns the currency of a denom, with its decimals and metadata
+	Currency(denom string) *types.Currency
 }
 
 // Client defines the API the client implementation should provide.
@@ -31,6 +37,9 @@ type Client interface {
 	// when the rosetta instance might come up before the node itself
 	// the servicer must return nil if the node is ready
 	Ready() error
+	// Close releases the resources acquired by Bootstrap, such as the
+	// connections to the node. The client must not be used after Close.
+	Close() error
 	// GenesisBlock gets the genesis block of the chain
 	GenesisBlock(ctx context.Context) (BlockResponse, error)
 	// InitialHeightBlock gets block with height InitialHeight
@@ -43,8 +52,12 @@ type Client interface {
 
 	// Balances fetches the balance of the given address
 	// if height is not nil, then the balance will be displayed
-	// at the provided height, otherwise last block balance will be returned
-	Balances(ctx context.Context, addr string, height *int64) ([]*types.Amount, error)
+	// at the provided height, otherwise last block balance will be returned.
+	// If currencies are provided only their balances are returned.
+	Balances(ctx context.Context, addr string, height *int64, currencies []*types.Currency) ([]*types.Amount, error)
+	// SubAccountBalances fetches the balance of a sub account of the given address, such as its
+	// delegated tokens, at the provided height. If currencies are provided only their balances are returned.
+	SubAccountBalances(ctx context.Context, addr string, subAccount *types.SubAccountIdentifier, height *int64, currencies []*types.Currency) ([]*types.Amount, error)
 	// BlockByHash gets a block and its transaction at the provided height
 	BlockByHash(ctx context.Context, hash string) (BlockResponse, error)
 	// BlockByHeight gets a block given its height, if height is nil then last block is returned
@@ -55,11 +68,18 @@ type Client interface {
 	// BlockTransactionsByHeight gets the block, parent block and transactions
 	// given the block hash.
 	BlockTransactionsByHeight(ctx context.Context, height *int64) (BlockTransactionsResponse, error)
+	// BlockEvents returns up to limit block events from the offset sequence, or the last limit events
+	// if offset is nil, and the maximum sequence available
+	BlockEvents(ctx context.Context, offset, limit *int64) (maxSequence int64, events []*types.BlockEvent, err error)
 	// GetTx gets a transaction given its hash
 	GetTx(ctx context.Context, hash string) (*types.Transaction, error)
+	// SearchTransactions searches the transactions matching the request conditions
+	SearchTransactions(ctx context.Context, request *types.SearchTransactionsRequest) (*types.SearchTransactionsResponse, error)
 	// GetUnconfirmedTx gets an unconfirmed Tx given its hash
 	// NOTE(fdymylja): NOT IMPLEMENTED YET!
 	GetUnconfirmedTx(ctx context.Context, hash string) (*types.Transaction, error)
+	// Call invokes the given method with the parameters and returns its result
+	Call(ctx context.Context, method string, parameters map[string]interface{}) (map[string]interface{}, error)
 	// Mempool returns the list of the current non confirmed transactions
 	Mempool(ctx context.Context) ([]*types.TransactionIdentifier, error)
 	// Peers gets the peers currently connected to the node
@@ -71,12 +91,31 @@ type Client interface {
 
 	// PostTx posts txBytes to the node and returns the transaction identifier plus metadata related
 	// to the transaction itself.
-	PostTx(txBytes []byte) (res *types.TransactionIdentifier, meta map[string]interface{}, err error)
+	PostTx(ctx context.Context, txBytes []byte) (res *types.TransactionIdentifier, meta map[string]interface{}, err error)
 	// ConstructionMetadataFromOptions builds metadata map from an option map
 	ConstructionMetadataFromOptions(ctx context.Context, options map[string]interface{}) (meta map[string]interface{}, err error)
 	OfflineClient
 }
 
+// SyncComponent is the name of the health component reporting whether the node is synced,
+// a node which is catching up is not ready to serve requests but it is still reachable
+const SyncComponent = "sync"
+
+// HealthChecker can be implemented by a Client to report the health of each of the
+// node components it depends on, if not implemented the health is checked using Ready
+type HealthChecker interface {
+	// Health returns the health of the node components keyed by component name,
+	// a nil error means the component is healthy
+	Health(ctx context.Context) map[string]error
+}
+
+// Reconnector can be implemented by a Client to replace its connections to the node,
+// it is called while the node is unavailable to recover from node restarts
+type Reconnector interface {
+	// Reconnect opens new connections to the node and closes the previous ones
+	Reconnect() error
+}
+
 // OfflineClient defines the functionalities supported without having access to the node
 type OfflineClient interface {
 	NetworkInformationProvider
@@ -84,13 +123,13 @@ type OfflineClient interface {
 	SignedTx(ctx context.Context, txBytes []byte, sigs []*types.Signature) (signedTxBytes []byte, err error)
 	// TxOperationsAndSignersAccountIdentifiers returns the operations related to a transaction and the account
 	// identifiers if the transaction is signed
-	TxOperationsAndSignersAccountIdentifiers(signed bool, hexBytes []byte) (ops []*types.Operation, signers []*types.AccountIdentifier, err error)
+	TxOperationsAndSignersAccountIdentifiers(ctx context.Context, signed bool, hexBytes []byte) (ops []*types.Operation, signers []*types.AccountIdentifier, err error)
 	// ConstructionPayload returns the construction payload given the request
 	ConstructionPayload(ctx context.Context, req *types.ConstructionPayloadsRequest) (resp *types.ConstructionPayloadsResponse, err error)
 	// PreprocessOperationsToOptions returns the options given the preprocess operations
 	PreprocessOperationsToOptions(ctx context.Context, req *types.ConstructionPreprocessRequest) (resp *types.ConstructionPreprocessResponse, err error)
 	// AccountIdentifierFromPublicKey returns the account identifier given the public key
-	AccountIdentifierFromPublicKey(pubKey *types.PublicKey) (*types.AccountIdentifier, error)
+	AccountIdentifierFromPublicKey(ctx context.Context, pubKey *types.PublicKey) (*types.AccountIdentifier, error)
 }
 
 type BlockTransactionsResponse struct {
@@ -103,6 +142,7 @@ type BlockResponse struct {
 	ParentBlock          *types.BlockIdentifier
 	MillisecondTimestamp int64
 	TxCount              int64
+	Metadata             map[string]interface{}
 }
 
 // API defines the exposed APIs
@@ -118,6 +158,9 @@ type DataAPI interface {
 	server.AccountAPIServicer
 	server.BlockAPIServicer
 	server.MempoolAPIServicer
+	server.SearchAPIServicer
+	server.EventsAPIServicer
+	server.CallAPIServicer
 }
 
 var _ server.ConstructionAPIServicer = ConstructionAPI(nil)
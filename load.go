@@ -3,15 +3,12 @@ package rosetta
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -24,11 +21,26 @@ import (
 )
 
 func ReflectInterfaces(ir codectypes.InterfaceRegistry, endpoint string) (err error) {
+	return reflectInterfaces(ir, endpoint, nodeTransport{})
+}
+
+// ReflectInterfaces registers the types reflected from the gRPC endpoint, the connection
+// is secured and authenticated as the connections to the node endpoints
+func (c *Config) ReflectInterfaces(ir codectypes.InterfaceRegistry, endpoint string) error {
+	transport, err := c.nodeTransport()
+	if err != nil {
+		return err
+	}
+	return reflectInterfaces(ir, endpoint, transport)
+}
+
+func reflectInterfaces(ir codectypes.InterfaceRegistry, endpoint string, transport nodeTransport) (err error) {
 	ctx := context.Background()
-	client, err := openClient(endpoint)
+	client, err := openClient(endpoint, transport)
 	if err != nil {
 		return crgerrs.WrapError(crgerrs.ErrClient, fmt.Sprintf("While opening client %s", err.Error()))
 	}
+	defer client.Close()
 
 	fdSet, err := getFileDescriptorSet(ctx, client)
 	if err != nil {
@@ -43,16 +55,14 @@ func ReflectInterfaces(ir codectypes.InterfaceRegistry, endpoint string) (err er
 	return nil
 }
 
-func openClient(endpoint string) (client *grpc.ClientConn, err error) {
-	creds := insecure.NewCredentials()
-	if strings.HasPrefix(endpoint, "https://") {
-		tlsConfig := &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		}
-		creds = credentials.NewTLS(tlsConfig)
+func openClient(endpoint string, transport nodeTransport) (client *grpc.ClientConn, err error) {
+	target, creds := grpcCredentials(endpoint, transport.tls, transport.tlsConfig())
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if len(transport.grpcHeaders) > 0 {
+		opts = append(opts, grpc.WithPerRPCCredentials(headerCredentials{headers: transport.grpcHeaders, insecure: transport.insecureHeaders}))
 	}
 
-	client, err = grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	client, err = grpc.NewClient(target, opts...)
 	if err != nil {
 		return nil, crgerrs.WrapError(crgerrs.ErrClient, fmt.Sprintf("getting grpc client connection %s", err.Error()))
 	}
@@ -0,0 +1,99 @@
+package rosetta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// node backends label values
+const (
+	backendCometBFT = "cometbft"
+	backendGRPC     = "grpc"
+)
+
+var (
+	nodeRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "rosetta",
+		Subsystem: "node",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the requests made to the node by backend and method.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	}, []string{"backend", "method"})
+
+	nodeRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "rosetta",
+		Subsystem: "node",
+		Name:      "request_errors_total",
+		Help:      "Number of failed requests made to the node by backend and method.",
+	}, []string{"backend", "method"})
+)
+
+// observeNodeRequest records the latency and the outcome of a request made to the node
+func observeNodeRequest(backend, method string, start time.Time, failed bool) {
+	nodeRequestDuration.WithLabelValues(backend, method).Observe(time.Since(start).Seconds())
+	if failed {
+		nodeRequestErrors.WithLabelValues(backend, method).Inc()
+	}
+}
+
+// metricsUnaryInterceptor instruments the gRPC calls made to the node
+func metricsUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	observeNodeRequest(backendGRPC, method, start, err != nil)
+	return err
+}
+
+// cometTransport instruments the json-rpc requests made to CometBFT with metrics
+// and a span, the method label is the json-rpc method found in the request body
+type cometTransport struct {
+	next http.RoundTripper
+}
+
+func (t cometTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := jsonRPCMethod(req)
+	ctx, span := tracer.Start(req.Context(), backendCometBFT+"/"+method, trace.WithSpanKind(trace.SpanKindClient))
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	reqErr := err
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		reqErr = fmt.Errorf("unexpected http status %s", resp.Status)
+	}
+	observeNodeRequest(backendCometBFT, method, start, reqErr != nil)
+	endSpan(span, reqErr)
+	return resp, err
+}
+
+// jsonRPCMethod extracts the json-rpc method of the request without consuming its body
+func jsonRPCMethod(req *http.Request) string {
+	const unknownMethod = "unknown"
+	if req.GetBody == nil {
+		return unknownMethod
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return unknownMethod
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return unknownMethod
+	}
+	var rpcReq struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(b, &rpcReq); err != nil || rpcReq.Method == "" {
+		return unknownMethod
+	}
+	return rpcReq.Method
+}
@@ -0,0 +1,116 @@
+package rosetta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// NetworkConfig defines an additional network served by the same rosetta
+// instance. Each network has its own endpoints, bech32 prefix and codec,
+// the settings which are not provided are inherited from the main network.
+type NetworkConfig struct {
+	// Blockchain defines the blockchain name
+	Blockchain string `json:"blockchain"`
+	// Network defines the network name
+	Network string `json:"network"`
+	// TendermintRPC defines the CometBFT RPC endpoint of the network
+	TendermintRPC string `json:"tendermint"`
+	// GRPCEndpoint defines the gRPC endpoint of the network
+	GRPCEndpoint string `json:"grpc"`
+	// ArchiveTendermintRPC defines the CometBFT RPC endpoint of the archive node of the network
+	ArchiveTendermintRPC string `json:"archive_tendermint,omitempty"`
+	// ArchiveGRPCEndpoint defines the gRPC endpoint of the archive node of the network
+	ArchiveGRPCEndpoint string `json:"archive_grpc,omitempty"`
+	// PruningWindow defines the number of recent blocks kept by the nodes of the network
+	PruningWindow int64 `json:"pruning_window,omitempty"`
+	// GRPCHeaders defines the headers sent with every gRPC query to the nodes of the network
+	GRPCHeaders map[string]string `json:"grpc_headers,omitempty"`
+	// TendermintHeaders defines the headers sent with every CometBFT RPC request to the nodes of the network
+	TendermintHeaders map[string]string `json:"tendermint_headers,omitempty"`
+	// GRPCTypesServerEndpoint defines the gRPC endpoint used to reflect the network types
+	GRPCTypesServerEndpoint string `json:"grpc_types_server,omitempty"`
+	// Plugin defines the plugin folder name used to register the network types
+	Plugin string `json:"plugin,omitempty"`
+	// Bech32Prefix defines the prefix used for bech32 addresses in the network
+	Bech32Prefix string `json:"bech32_prefix"`
+	// DenomToSuggest overrides the denom used for fee suggestion
+	DenomToSuggest string `json:"denom_to_suggest,omitempty"`
+	// PricesToSuggest overrides the gas prices used for fee suggestion
+	PricesToSuggest string `json:"prices_to_suggest,omitempty"`
+	// DenomMetadataFile defines the json file listing the bank denom metadata of the network
+	DenomMetadataFile string `json:"denom_metadata_file,omitempty"`
+}
+
+// LoadNetworksFile reads the additional networks from a json file containing a list of NetworkConfig
+func LoadNetworksFile(path string) ([]NetworkConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("reading networks file %s", err.Error()))
+	}
+
+	var networks []NetworkConfig
+	if err := json.Unmarshal(b, &networks); err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("decoding networks file %s", err.Error()))
+	}
+	return networks, nil
+}
+
+// networkConfig derives the configuration of an additional network from the main one,
+// the network gets its own codec built with its bech32 prefix and, if provided, the
+// types loaded from its plugin or reflected from its gRPC types server.
+func (c *Config) networkConfig(network NetworkConfig) (*Config, error) {
+	if network.Bech32Prefix == "" {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("bech32 prefix not provided for network %s", network.Network))
+	}
+
+	conf := *c
+	conf.Networks = nil
+	conf.Network = network.Network
+	conf.TendermintRPC = network.TendermintRPC
+	conf.GRPCEndpoint = network.GRPCEndpoint
+	conf.ArchiveTendermintRPC = network.ArchiveTendermintRPC
+	conf.ArchiveGRPCEndpoint = network.ArchiveGRPCEndpoint
+	conf.PruningWindow = network.PruningWindow
+	// the credentials of the main network nodes are not sent to other nodes
+	conf.GRPCHeaders = network.GRPCHeaders
+	conf.TendermintHeaders = network.TendermintHeaders
+	conf.Bech32Prefix = network.Bech32Prefix
+	// the denom metadata of the main network does not apply to other chains
+	conf.DenomMetadataFile = network.DenomMetadataFile
+	if network.Blockchain != "" {
+		conf.Blockchain = network.Blockchain
+	}
+	if network.DenomToSuggest != "" {
+		conf.DenomToSuggest = network.DenomToSuggest
+	}
+	if network.PricesToSuggest != "" {
+		prices, err := sdk.ParseDecCoins(network.PricesToSuggest)
+		if err != nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("parsing prices of network %s %s", network.Network, err.Error()))
+		}
+		conf.GasPrices = prices
+	}
+
+	cdc, ir := MakeCodecWithBech32Prefix(network.Bech32Prefix)
+	switch {
+	case network.Plugin != "":
+		if err := LoadPlugin(ir, network.Plugin); err != nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrPlugin, fmt.Sprintf("loading plugin of network %s %s", network.Network, err.Error()))
+		}
+	case network.GRPCTypesServerEndpoint != "":
+		if err := conf.ReflectInterfaces(ir, network.GRPCTypesServerEndpoint); err != nil {
+			return nil, crgerrs.WrapError(crgerrs.ErrClient, fmt.Sprintf("reflecting types of network %s %s", network.Network, err.Error()))
+		}
+	}
+	conf.WithCodec(ir, cdc)
+
+	if err := conf.validate(); err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("validating network %s %s", network.Network, err.Error()))
+	}
+	return &conf, nil
+}
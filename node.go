@@ -0,0 +1,93 @@
+package rosetta
+
+import (
+	"fmt"
+	nethttp "net/http"
+
+	tmrpc "github.com/cometbft/cometbft/rpc/client"
+	"github.com/cometbft/cometbft/rpc/client/http"
+	jsonrpcclient "github.com/cometbft/cometbft/rpc/jsonrpc/client"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+
+	distributionv1beta1 "cosmossdk.io/api/cosmos/distribution/v1beta1"
+	stakingv1beta1 "cosmossdk.io/api/cosmos/staking/v1beta1"
+	bank "cosmossdk.io/x/bank/types"
+
+	auth "github.com/cosmos/cosmos-sdk/x/auth/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// node groups the connections to a node, they are replaced
+// as a whole when the client reconnects to the node
+type node struct {
+	auth         auth.QueryClient
+	bank         bank.QueryClient
+	staking      stakingv1beta1.QueryClient
+	distribution distributionv1beta1.QueryClient
+	tmRPC        tmrpc.Client
+	// invoker invokes the gRPC queries served by /call
+	invoker grpc.ClientConnInterface
+
+	// grpcConn and httpClient are kept to release
+	// the node connections when the node is closed
+	grpcConn   *grpc.ClientConn
+	httpClient *nethttp.Client
+}
+
+// dialNode opens the connections to the gRPC and CometBFT RPC endpoints of the node
+func dialNode(endpoints nodeEndpoints, transport nodeTransport) (*node, error) {
+	target, creds := grpcCredentials(endpoints.grpc, transport.tls, transport.tlsConfig())
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(metricsUnaryInterceptor),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+	if len(transport.grpcHeaders) > 0 {
+		opts = append(opts, grpc.WithPerRPCCredentials(headerCredentials{headers: transport.grpcHeaders, insecure: transport.insecureHeaders}))
+	}
+	grpcConn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("dialing grpc endpoint %s", err.Error()))
+	}
+
+	httpClient, err := jsonrpcclient.DefaultHTTPClient(endpoints.tendermintRPC)
+	if err != nil {
+		_ = grpcConn.Close()
+		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("creating rpc http client %s", err.Error()))
+	}
+	if t, ok := httpClient.Transport.(*nethttp.Transport); ok {
+		t.TLSClientConfig = transport.tlsConfig()
+	}
+	if len(transport.tendermintHeaders) > 0 {
+		httpClient.Transport = headerTransport{headers: transport.tendermintHeaders, insecure: transport.insecureHeaders, next: httpClient.Transport}
+	}
+	httpClient.Transport = cometTransport{next: httpClient.Transport}
+
+	tmRPC, err := http.NewWithClient(endpoints.tendermintRPC, httpClient)
+	if err != nil {
+		_ = grpcConn.Close()
+		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting rpc path %s", err.Error()))
+	}
+
+	return &node{
+		auth:         auth.NewQueryClient(grpcConn),
+		bank:         bank.NewQueryClient(grpcConn),
+		staking:      stakingv1beta1.NewQueryClient(grpcConn),
+		distribution: distributionv1beta1.NewQueryClient(grpcConn),
+		tmRPC:        tmRPC,
+		invoker:      grpcConn,
+		grpcConn:     grpcConn,
+		httpClient:   httpClient,
+	}, nil
+}
+
+// close releases the connections to the node
+func (n *node) close() error {
+	n.httpClient.CloseIdleConnections()
+	if err := n.grpcConn.Close(); err != nil {
+		return crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("closing grpc connection %s", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package rosetta
+
+import (
+	rosettatypes "github.com/coinbase/rosetta-sdk-go/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+
+	"cosmossdk.io/core/address"
+	stakingtypes "cosmossdk.io/x/staking/types"
+
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+// names of the module accounts the balance operations are classified from, the mint,
+// distribution and gov modules are not dependencies of rosetta
+const (
+	mintModuleName         = "mint"
+	distributionModuleName = "distribution"
+	govModuleName          = "gov"
+)
+
+// module events the balance operations are classified from
+const (
+	eventTypeMint               = "mint"
+	eventTypeWithdrawRewards    = "withdraw_rewards"
+	eventTypeWithdrawCommission = "withdraw_commission"
+	eventTypeProposalDeposit    = "proposal_deposit"
+	eventTypeActiveProposal     = "active_proposal"
+	eventTypeInactiveProposal   = "inactive_proposal"
+	eventTypeCancelProposal     = "cancel_proposal"
+)
+
+// semanticOperationTypes are the types of the classified balance operations
+var semanticOperationTypes = []string{
+	OperationMint,
+	OperationReward,
+	OperationDelegate,
+	OperationUnbond,
+	OperationGovDeposit,
+	OperationGovRefund,
+}
+
+// moduleAccounts returns the names of the module accounts the balance operations are
+// classified from, keyed by their address
+func moduleAccounts(ac address.Codec) map[string]string {
+	names := []string{
+		mintModuleName,
+		distributionModuleName,
+		govModuleName,
+		stakingtypes.BondedPoolName,
+		stakingtypes.NotBondedPoolName,
+	}
+	accounts := make(map[string]string, len(names))
+	for _, name := range names {
+		addr, err := ac.BytesToString(authtypes.NewModuleAddress(name))
+		if err != nil {
+			continue
+		}
+		accounts[addr] = name
+	}
+	return accounts
+}
+
+// eventScopes returns the types of the events emitted by each message keyed by message index,
+// the events not emitted by a message, such as the block events, are keyed by -1
+func eventScopes(events []abci.Event) map[int]map[string]bool {
+	scopes := make(map[int]map[string]bool)
+	for _, e := range events {
+		msgIndex, err := eventMsgIndex(e)
+		if err != nil {
+			continue
+		}
+		if scopes[msgIndex] == nil {
+			scopes[msgIndex] = make(map[string]bool)
+		}
+		scopes[msgIndex][e.Type] = true
+	}
+	return scopes
+}
+
+// operationType returns the semantic type of the operations of a transfer, given the types of
+// the events emitted along with it. The debits are nil for coins credited without a debit, such
+// as minted coins. An empty type is returned if the transfer is not classified.
+func (c converter) operationType(debits, credits []*rosettatypes.Operation, events map[string]bool) string {
+	var from string
+	if len(debits) != 0 {
+		from = c.modules[debits[0].Account.Address]
+	}
+	to := c.modules[credits[0].Account.Address]
+	// the accounts which are not a classified module account are user accounts
+	fromUser, toUser := len(debits) != 0 && from == "", to == ""
+
+	switch {
+	// the inflation is minted to the mint module then sent to the fee collector
+	case (from == mintModuleName || debits == nil && to == mintModuleName) && events[eventTypeMint]:
+		return OperationMint
+	case from == distributionModuleName && toUser && (events[eventTypeWithdrawRewards] || events[eventTypeWithdrawCommission]):
+		return OperationReward
+	case fromUser && (to == stakingtypes.BondedPoolName || to == stakingtypes.NotBondedPoolName) && events[stakingtypes.EventTypeDelegate]:
+		return OperationDelegate
+	case from == stakingtypes.NotBondedPoolName && toUser && events[stakingtypes.EventTypeCompleteUnbonding]:
+		return OperationUnbond
+	case fromUser && to == govModuleName && events[eventTypeProposalDeposit]:
+		return OperationGovDeposit
+	case from == govModuleName && toUser && (events[eventTypeActiveProposal] || events[eventTypeInactiveProposal] || events[eventTypeCancelProposal]):
+		return OperationGovRefund
+	}
+	return ""
+}
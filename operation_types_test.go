@@ -0,0 +1,106 @@
+package rosetta
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/stretchr/testify/require"
+
+	bank "cosmossdk.io/x/bank/types"
+	stakingtypes "cosmossdk.io/x/staking/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+)
+
+// transferEvents returns the events of a transfer emitted by the message msgIndex, -1 for the block
+func transferEvents(from, to, amount string, msgIndex int) []abcitypes.Event {
+	events := []abcitypes.Event{
+		abcitypes.Event(sdk.NewEvent(bank.EventTypeCoinSpent, sdk.NewAttribute(bank.AttributeKeySpender, from), sdk.NewAttribute(sdk.AttributeKeyAmount, amount))),
+		abcitypes.Event(sdk.NewEvent(bank.EventTypeCoinReceived, sdk.NewAttribute(bank.AttributeKeyReceiver, to), sdk.NewAttribute(sdk.AttributeKeyAmount, amount))),
+	}
+	if msgIndex < 0 {
+		return events
+	}
+	for i := range events {
+		events[i].Attributes = append(events[i].Attributes, abcitypes.EventAttribute{Key: attributeKeyMsgIndex, Value: strconv.Itoa(msgIndex)})
+	}
+	return events
+}
+
+// moduleEvent returns an event without attributes emitted by the message msgIndex, -1 for the block
+func moduleEvent(eventType string, msgIndex int) abcitypes.Event {
+	event := abcitypes.Event{Type: eventType}
+	if msgIndex >= 0 {
+		event.Attributes = []abcitypes.EventAttribute{{Key: attributeKeyMsgIndex, Value: strconv.Itoa(msgIndex)}}
+	}
+	return event
+}
+
+func TestClient_OperationTypes(t *testing.T) {
+	module := func(name string) string {
+		return authtypes.NewModuleAddress(name).String()
+	}
+	user := sdk.AccAddress("user").String()
+
+	blockEvents := []abcitypes.Event{
+		abcitypes.Event(sdk.NewEvent(bank.EventTypeCoinReceived, sdk.NewAttribute(bank.AttributeKeyReceiver, module(mintModuleName)), sdk.NewAttribute(sdk.AttributeKeyAmount, "10stake"))),
+		abcitypes.Event(sdk.NewEvent(bank.EventTypeCoinMint, sdk.NewAttribute(bank.AttributeKeyMinter, module(mintModuleName)), sdk.NewAttribute(sdk.AttributeKeyAmount, "10stake"))),
+	}
+	blockEvents = append(blockEvents, transferEvents(module(mintModuleName), module(authtypes.FeeCollectorName), "10stake", -1)...)
+	blockEvents = append(blockEvents, moduleEvent(eventTypeMint, -1))
+	blockEvents = append(blockEvents, transferEvents(module(authtypes.FeeCollectorName), module(distributionModuleName), "10stake", -1)...)
+	blockEvents = append(blockEvents, transferEvents(module(stakingtypes.NotBondedPoolName), user, "5stake", -1)...)
+	blockEvents = append(blockEvents, moduleEvent(stakingtypes.EventTypeCompleteUnbonding, -1))
+	blockEvents = append(blockEvents, transferEvents(module(govModuleName), user, "3stake", -1)...)
+	blockEvents = append(blockEvents, moduleEvent(eventTypeInactiveProposal, -1))
+
+	txEvents := transferEvents(user, module(stakingtypes.BondedPoolName), "5stake", 0)
+	txEvents = append(txEvents, moduleEvent(stakingtypes.EventTypeDelegate, 0))
+	txEvents = append(txEvents, transferEvents(module(distributionModuleName), user, "1stake", 1)...)
+	txEvents = append(txEvents, moduleEvent(eventTypeWithdrawRewards, 1))
+	txEvents = append(txEvents, transferEvents(user, module(govModuleName), "3stake", 2)...)
+	txEvents = append(txEvents, moduleEvent(eventTypeProposalDeposit, 2))
+	// the withdrawal event of another message does not classify the transfer
+	txEvents = append(txEvents, transferEvents(module(distributionModuleName), user, "1stake", 3)...)
+
+	types := func(client *Client, events []abcitypes.Event) []string {
+		ops, err := client.converter.ToRosetta().BalanceOps(StatusTxSuccess, events)
+		require.NoError(t, err)
+		types := make([]string, len(ops))
+		for i, op := range ops {
+			types[i] = op.Type
+		}
+		return types
+	}
+
+	client := newTestClient(t, &Config{}, &node{})
+	require.Subset(t, client.SupportedOperations(), []string{OperationMint, OperationReward, OperationDelegate, OperationUnbond, OperationGovDeposit, OperationGovRefund})
+	require.Equal(t, []string{
+		OperationMint, OperationMint, OperationMint,
+		bank.EventTypeCoinSpent, bank.EventTypeCoinReceived,
+		OperationUnbond, OperationUnbond,
+		OperationGovRefund, OperationGovRefund,
+	}, types(client, blockEvents))
+	require.Equal(t, []string{
+		OperationDelegate, OperationDelegate,
+		OperationReward, OperationReward,
+		OperationGovDeposit, OperationGovDeposit,
+		bank.EventTypeCoinSpent, bank.EventTypeCoinReceived,
+	}, types(client, txEvents))
+
+	// the fee transfer is followed by the tx event of the ante handler
+	feeEvents := transferEvents(user, module(authtypes.FeeCollectorName), "2stake", -1)
+	feeEvents = append(feeEvents, abcitypes.Event(sdk.NewEvent(sdk.EventTypeTx, sdk.NewAttribute(sdk.AttributeKeyFee, "2stake"), sdk.NewAttribute(sdk.AttributeKeyFeePayer, user))))
+	require.Equal(t, []string{OperationFee, OperationFee}, types(client, feeEvents))
+
+	// the raw operation types are the event types, the fee operations included
+	client = newTestClient(t, &Config{RawOperationTypes: true}, &node{})
+	require.NotContains(t, client.SupportedOperations(), OperationMint)
+	require.NotContains(t, client.SupportedOperations(), OperationFee)
+	for _, opType := range types(client, slices.Concat(blockEvents, txEvents, feeEvents)) {
+		require.Contains(t, []string{bank.EventTypeCoinSpent, bank.EventTypeCoinReceived}, opType)
+	}
+}
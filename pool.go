@@ -0,0 +1,270 @@
+package rosetta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bank "cosmossdk.io/x/bank/types"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// maxNodeLag is the number of blocks the selected node can fall behind
+// the highest node before the requests fail over to another node
+const maxNodeLag = 2
+
+// nodeCheckTimeout is the time given to a node to answer its health check, a node which
+// does not answer in time is unreachable and does not hold up the checks of the other nodes
+const nodeCheckTimeout = 10 * time.Second
+
+// nodeEndpoints defines the gRPC and CometBFT RPC endpoints of a node
+type nodeEndpoints struct {
+	grpc          string
+	tendermintRPC string
+}
+
+// nodeStatus is the status of a node as of its last check
+type nodeStatus struct {
+	// health contains the health of the node components
+	health map[string]error
+	// reachable reports whether both the gRPC and the CometBFT endpoints answered
+	reachable      bool
+	catchingUp     bool
+	earliestHeight int64
+	latestHeight   int64
+}
+
+// synced reports whether the node can serve requests
+func (s *nodeStatus) synced() bool {
+	return s != nil && s.reachable && !s.catchingUp
+}
+
+// hasHeight reports whether the node has the state at the given height,
+// a node which was not checked yet is assumed to have it
+func (s *nodeStatus) hasHeight(height int64) bool {
+	if s == nil {
+		return true
+	}
+	return s.reachable && s.earliestHeight <= height && height <= s.latestHeight
+}
+
+// poolNode is a node of the pool, its connections are replaced when it is redialed
+type poolNode struct {
+	endpoints nodeEndpoints
+	conn      atomic.Pointer[node]
+	status    atomic.Pointer[nodeStatus]
+}
+
+// nodePool holds the connections to the nodes of a network, the requests are served by the
+// selected node which is replaced when it stops responding or falls behind the other nodes.
+// The queries at heights pruned by the selected node are served by the archive node, if any.
+type nodePool struct {
+	nodes    []*poolNode
+	selected atomic.Pointer[poolNode]
+	// archive keeps the full history, it is never selected, nil if not configured
+	archive *poolNode
+	// pruningWindow is the number of recent blocks kept by the nodes, 0 if unknown
+	pruningWindow int64
+	// transport secures and authenticates the connections to every node
+	transport nodeTransport
+}
+
+func newNodePool(endpoints []nodeEndpoints, archive *nodeEndpoints, pruningWindow int64, transport nodeTransport) *nodePool {
+	pool := &nodePool{nodes: make([]*poolNode, len(endpoints)), pruningWindow: pruningWindow, transport: transport}
+	for i, e := range endpoints {
+		pool.nodes[i] = &poolNode{endpoints: e}
+	}
+	pool.selected.Store(pool.nodes[0])
+	if archive != nil {
+		pool.archive = &poolNode{endpoints: *archive}
+	}
+	return pool
+}
+
+// all returns every node of the pool, including the archive node
+func (p *nodePool) all() []*poolNode {
+	if p.archive == nil {
+		return p.nodes
+	}
+	return append(p.nodes[:len(p.nodes):len(p.nodes)], p.archive)
+}
+
+// dial opens new connections to the nodes which are not connected or did not respond to their
+// last check, and closes their previous connections. The healthy nodes keep their connections.
+func (p *nodePool) dial() error {
+	var err error
+	for _, n := range p.all() {
+		if status := n.status.Load(); n.conn.Load() != nil && (status == nil || status.reachable) {
+			continue
+		}
+		conn, dialErr := dialNode(n.endpoints, p.transport)
+		if dialErr != nil {
+			err = errors.Join(err, dialErr)
+			continue
+		}
+		if old := n.conn.Swap(conn); old != nil {
+			err = errors.Join(err, old.close())
+		}
+	}
+	return err
+}
+
+// close closes the connections to every node
+func (p *nodePool) close() error {
+	var err error
+	for _, n := range p.all() {
+		if conn := n.conn.Swap(nil); conn != nil {
+			err = errors.Join(err, conn.close())
+		}
+	}
+	return err
+}
+
+// node returns the connections to the selected node
+func (p *nodePool) node() *node {
+	return p.selected.Load().conn.Load()
+}
+
+// nodeAt returns the connections to a node having the state at the given height. The heights pruned
+// by the selected node are served by the archive node, otherwise the selected node is preferred.
+// If no node is known to have the height the selected node is returned. A nil
+// or zero height, which the nodes resolve to their latest height, is not routed.
+func (p *nodePool) nodeAt(height *int64) *node {
+	selected := p.selected.Load()
+	if height == nil || *height <= 0 {
+		return selected.conn.Load()
+	}
+	if p.pruned(selected.status.Load(), *height) {
+		if archive := p.archiveNode(); archive != nil {
+			return archive
+		}
+	}
+	if selected.status.Load().hasHeight(*height) {
+		return selected.conn.Load()
+	}
+	for _, n := range p.nodes {
+		if n.status.Load().hasHeight(*height) && n.conn.Load() != nil {
+			return n.conn.Load()
+		}
+	}
+	return selected.conn.Load()
+}
+
+// archiveNode returns the connections to the archive node, nil if it is not
+// configured, not connected or if it did not respond to its last check
+func (p *nodePool) archiveNode() *node {
+	if p.archive == nil {
+		return nil
+	}
+	if status := p.archive.status.Load(); status != nil && !status.reachable {
+		return nil
+	}
+	return p.archive.conn.Load()
+}
+
+// pruned reports whether the state at height was pruned by a node, the height is pruned if it is below
+// the earliest height of the node or, as the node keeps pruning between checks, outside of the pruning window
+func (p *nodePool) pruned(status *nodeStatus, height int64) bool {
+	if status == nil || status.latestHeight == 0 {
+		return false
+	}
+	if height < status.earliestHeight {
+		return true
+	}
+	return p.pruningWindow > 0 && height <= status.latestHeight-p.pruningWindow
+}
+
+// refresh checks every node concurrently and selects the node serving the requests,
+// it returns the status of the selected node
+func (p *nodePool) refresh(ctx context.Context) *nodeStatus {
+	var wg sync.WaitGroup
+	for _, n := range p.all() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, nodeCheckTimeout)
+			defer cancel()
+			n.status.Store(n.check(ctx))
+		}()
+	}
+	wg.Wait()
+	p.selectNode()
+	return p.selected.Load().status.Load()
+}
+
+// selectNode selects the highest synced node, the current node is kept
+// as long as it is synced and at most maxNodeLag blocks behind it
+func (p *nodePool) selectNode() {
+	var best *poolNode
+	for _, n := range p.nodes {
+		status := n.status.Load()
+		if !status.synced() {
+			continue
+		}
+		if best == nil || status.latestHeight > best.status.Load().latestHeight {
+			best = n
+		}
+	}
+	if best == nil {
+		// no node can serve the requests, keep the current one
+		return
+	}
+
+	current := p.selected.Load()
+	if status := current.status.Load(); status.synced() && status.latestHeight+maxNodeLag >= best.status.Load().latestHeight {
+		return
+	}
+	p.selected.Store(best)
+}
+
+// check checks the health of the node components and fetches its sync status
+func (n *poolNode) check(ctx context.Context) *nodeStatus {
+	conn := n.conn.Load()
+	if conn == nil {
+		err := crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("node %s is not connected", n.endpoints.tendermintRPC))
+		return &nodeStatus{health: map[string]error{
+			HealthComponentCometBFT: err,
+			HealthComponentGRPC:     err,
+			HealthComponentSync:     err,
+		}}
+	}
+
+	status := &nodeStatus{health: make(map[string]error, 3), reachable: true}
+
+	_, err := conn.tmRPC.Health(ctx)
+	if err != nil {
+		status.reachable = false
+		status.health[HealthComponentCometBFT] = crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting cometbft health %s", err.Error()))
+	} else {
+		status.health[HealthComponentCometBFT] = nil
+	}
+
+	_, err = conn.bank.TotalSupply(ctx, &bank.QueryTotalSupplyRequest{})
+	if err != nil {
+		status.reachable = false
+		status.health[HealthComponentGRPC] = crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting bank total supply %s", err.Error()))
+	} else {
+		status.health[HealthComponentGRPC] = nil
+	}
+
+	syncInfo, err := conn.tmRPC.Status(ctx)
+	switch {
+	case err != nil:
+		status.reachable = false
+		status.health[HealthComponentSync] = crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("getting network information %s", err.Error()))
+	case syncInfo.SyncInfo.CatchingUp:
+		status.catchingUp = true
+		status.health[HealthComponentSync] = crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("node is %s at height %d", StatusPeerSyncing, syncInfo.SyncInfo.LatestBlockHeight))
+	default:
+		status.health[HealthComponentSync] = nil
+	}
+	if err == nil {
+		status.earliestHeight = syncInfo.SyncInfo.EarliestBlockHeight
+		status.latestHeight = syncInfo.SyncInfo.LatestBlockHeight
+	}
+	return status
+}
@@ -0,0 +1,153 @@
+package rosetta
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodePool(t *testing.T) {
+	pool := newNodePool([]nodeEndpoints{
+		{grpc: "sentry-0:9090", tendermintRPC: "http://sentry-0:26657"},
+		{grpc: "sentry-1:9090", tendermintRPC: "http://sentry-1:26657"},
+		{grpc: "sentry-2:9090", tendermintRPC: "http://sentry-2:26657"},
+	}, nil, 0, nodeTransport{})
+	conns := make([]*node, len(pool.nodes))
+	for i, n := range pool.nodes {
+		conns[i] = &node{}
+		n.conn.Store(conns[i])
+	}
+	setStatus := func(i int, status *nodeStatus) {
+		pool.nodes[i].status.Store(status)
+	}
+	height := func(h int64) *int64 { return &h }
+
+	// the first node is selected until the nodes are checked
+	require.Same(t, conns[0], pool.node())
+	require.Same(t, conns[0], pool.nodeAt(height(1)))
+
+	// the highest synced node is selected
+	setStatus(0, &nodeStatus{reachable: true, earliestHeight: 1, latestHeight: 100})
+	setStatus(1, &nodeStatus{reachable: true, earliestHeight: 50, latestHeight: 110})
+	setStatus(2, &nodeStatus{reachable: true, catchingUp: true, earliestHeight: 1, latestHeight: 200})
+	pool.selectNode()
+	require.Same(t, conns[1], pool.node())
+
+	// heights pruned by the selected node are served by a node having them
+	require.Same(t, conns[1], pool.nodeAt(nil))
+	require.Same(t, conns[1], pool.nodeAt(height(50)))
+	require.Same(t, conns[0], pool.nodeAt(height(10)))
+	// no node has the height
+	require.Same(t, conns[1], pool.nodeAt(height(300)))
+
+	// the selected node is kept while it lags by at most maxNodeLag blocks
+	setStatus(0, &nodeStatus{reachable: true, earliestHeight: 1, latestHeight: 110 + maxNodeLag})
+	pool.selectNode()
+	require.Same(t, conns[1], pool.node())
+
+	// the selected node falls behind
+	setStatus(0, &nodeStatus{reachable: true, earliestHeight: 1, latestHeight: 111 + maxNodeLag})
+	pool.selectNode()
+	require.Same(t, conns[0], pool.node())
+
+	// the selected node stops responding
+	setStatus(0, &nodeStatus{})
+	pool.selectNode()
+	require.Same(t, conns[1], pool.node())
+	// a node catching up still serves the heights it has
+	require.Same(t, conns[2], pool.nodeAt(height(10)))
+
+	// no node can serve the requests, the selected node is kept
+	setStatus(1, &nodeStatus{})
+	pool.selectNode()
+	require.Same(t, conns[1], pool.node())
+}
+
+func TestNodePool_archive(t *testing.T) {
+	pool := newNodePool(
+		[]nodeEndpoints{{grpc: "full:9090", tendermintRPC: "http://full:26657"}},
+		&nodeEndpoints{grpc: "archive:9090", tendermintRPC: "http://archive:26657"},
+		100,
+		nodeTransport{},
+	)
+	full, archive := &node{}, &node{}
+	pool.nodes[0].conn.Store(full)
+	pool.archive.conn.Store(archive)
+	height := func(h int64) *int64 { return &h }
+
+	// the archive node is never selected
+	pool.nodes[0].status.Store(&nodeStatus{reachable: true, earliestHeight: 850, latestHeight: 1000})
+	pool.archive.status.Store(&nodeStatus{reachable: true, earliestHeight: 1, latestHeight: 1000})
+	pool.selectNode()
+	require.Same(t, full, pool.node())
+
+	require.Same(t, full, pool.nodeAt(nil))
+	require.Same(t, full, pool.nodeAt(height(0)))
+	require.Same(t, full, pool.nodeAt(height(1000)))
+	require.Same(t, full, pool.nodeAt(height(901)))
+	// outside of the pruning window
+	require.Same(t, archive, pool.nodeAt(height(900)))
+	// below the earliest height
+	require.Same(t, archive, pool.nodeAt(height(10)))
+
+	require.Same(t, archive, pool.archiveNode())
+
+	// the archive node stops responding
+	pool.archive.status.Store(&nodeStatus{})
+	require.Same(t, full, pool.nodeAt(height(10)))
+	require.Nil(t, pool.archiveNode())
+}
+
+// hangingClient is a node which never answers its health check
+type hangingClient struct {
+	readyClient
+}
+
+func (hangingClient) Health(ctx context.Context) (*coretypes.ResultHealth, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestNodePool_refresh(t *testing.T) {
+	pool := newNodePool([]nodeEndpoints{
+		{grpc: "sentry-0:9090", tendermintRPC: "http://sentry-0:26657"},
+		{grpc: "sentry-1:9090", tendermintRPC: "http://sentry-1:26657"},
+	}, nil, 0, nodeTransport{})
+	hanging, healthy := &node{tmRPC: hangingClient{}, bank: readyClient{}}, &node{tmRPC: readyClient{}, bank: readyClient{}}
+	pool.nodes[0].conn.Store(hanging)
+	pool.nodes[1].conn.Store(healthy)
+
+	// the hanging node does not hold up the check of the other node
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	status := pool.refresh(ctx)
+	require.True(t, status.reachable)
+	require.Same(t, healthy, pool.node())
+	require.False(t, pool.nodes[0].status.Load().reachable)
+}
+
+func TestNodePool_dial(t *testing.T) {
+	pool := newNodePool([]nodeEndpoints{
+		{grpc: "sentry-0:9090", tendermintRPC: "http://sentry-0:26657"},
+		{grpc: "sentry-1:9090", tendermintRPC: "http://sentry-1:26657"},
+		{grpc: "sentry-2:9090", tendermintRPC: "http://sentry-2:26657"},
+	}, nil, 0, nodeTransport{})
+	require.NoError(t, pool.dial())
+	defer pool.close()
+	conns := make([]*node, len(pool.nodes))
+	for i, n := range pool.nodes {
+		conns[i] = n.conn.Load()
+		require.NotNil(t, conns[i])
+	}
+
+	// only the node which did not respond is redialed
+	pool.nodes[0].status.Store(&nodeStatus{reachable: true})
+	pool.nodes[1].status.Store(&nodeStatus{})
+	require.NoError(t, pool.dial())
+	require.Same(t, conns[0], pool.nodes[0].conn.Load())
+	require.NotSame(t, conns[1], pool.nodes[1].conn.Load())
+	require.Same(t, conns[2], pool.nodes[2].conn.Load())
+}
@@ -0,0 +1,28 @@
+package rosetta
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer traces the node calls and the converter stages of the client
+var tracer = otel.Tracer("github.com/cosmos/rosetta")
+
+// startConverterSpan opens the span of a converter stage,
+// it must be closed with endSpan once the stage completes
+func startConverterSpan(ctx context.Context, stage string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "converter."+stage, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, and closes the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,128 @@
+package rosetta
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	nethttp "net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	crgerrs "github.com/cosmos/rosetta/lib/errors"
+)
+
+// nodeTransport defines how the connections to the node endpoints are secured and authenticated
+type nodeTransport struct {
+	// tls enables TLS towards the gRPC endpoints, the CometBFT
+	// endpoints use TLS when their scheme is https
+	tls bool
+	// rootCAs verifies the node certificates, the system roots are used if nil
+	rootCAs *x509.CertPool
+	// grpcHeaders are sent with every gRPC query
+	grpcHeaders map[string]string
+	// tendermintHeaders are sent with every CometBFT RPC request
+	tendermintHeaders map[string]string
+	// insecureHeaders allows sending the headers over connections without TLS
+	insecureHeaders bool
+}
+
+// nodeTransport returns the transport of the node connections, loading the node CA if provided
+func (c *Config) nodeTransport() (nodeTransport, error) {
+	transport := nodeTransport{
+		tls:               c.NodeTLS,
+		grpcHeaders:       c.GRPCHeaders,
+		tendermintHeaders: c.TendermintHeaders,
+		insecureHeaders:   c.InsecureHeaders,
+	}
+	if c.NodeCAFile == "" {
+		return transport, nil
+	}
+
+	pem, err := os.ReadFile(c.NodeCAFile)
+	if err != nil {
+		return nodeTransport{}, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("reading node ca %s", err.Error()))
+	}
+	transport.rootCAs = x509.NewCertPool()
+	if !transport.rootCAs.AppendCertsFromPEM(pem) {
+		return nodeTransport{}, crgerrs.WrapError(crgerrs.ErrConfig, "node ca does not contain any pem certificate")
+	}
+	return transport, nil
+}
+
+// tlsConfig returns the TLS configuration used towards the node endpoints
+func (t nodeTransport) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    t.rootCAs,
+	}
+}
+
+// grpcCredentials returns the dial target and the transport credentials of a gRPC endpoint,
+// TLS is used if enabled or if the endpoint has the https scheme, which is not part of the target
+func grpcCredentials(endpoint string, useTLS bool, tlsConfig *tls.Config) (string, credentials.TransportCredentials) {
+	if target, ok := strings.CutPrefix(endpoint, "https://"); ok {
+		return target, credentials.NewTLS(tlsConfig)
+	}
+	if useTLS {
+		return endpoint, credentials.NewTLS(tlsConfig)
+	}
+	return endpoint, insecure.NewCredentials()
+}
+
+// headerCredentials sends the configured headers as metadata of every gRPC query,
+// they can be sent without TLS only if insecure is set
+type headerCredentials struct {
+	headers  map[string]string
+	insecure bool
+}
+
+func (h headerCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return h.headers, nil
+}
+
+// RequireTransportSecurity prevents sending the headers, which usually contain
+// credentials, in plaintext unless it was explicitly allowed
+func (h headerCredentials) RequireTransportSecurity() bool {
+	return !h.insecure
+}
+
+// headerTransport sets the configured headers on every CometBFT RPC request,
+// the requests without TLS are refused unless insecure is set
+type headerTransport struct {
+	headers  map[string]string
+	insecure bool
+	next     nethttp.RoundTripper
+}
+
+func (t headerTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	if req.URL.Scheme != HTTPS && !t.insecure {
+		return nil, crgerrs.WrapError(crgerrs.ErrOnlineClient, fmt.Sprintf("cometbft headers require tls, use an https endpoint or set --%s", FlagInsecureHeaders))
+	}
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// parseHeaders parses headers provided as name=value
+func parseHeaders(headers []string) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]string, len(headers))
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, crgerrs.WrapError(crgerrs.ErrConfig, fmt.Sprintf("invalid header %q, expected name=value", header))
+		}
+		// gRPC metadata keys are lowercase, http headers are case-insensitive
+		parsed[strings.ToLower(name)] = strings.TrimSpace(value)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,90 @@
+package rosetta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders([]string{"Authorization=Bearer token=", " x-api-key = key"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"authorization": "Bearer token=", "x-api-key": "key"}, headers)
+
+	headers, err = parseHeaders(nil)
+	require.NoError(t, err)
+	require.Nil(t, headers)
+
+	_, err = parseHeaders([]string{"authorization"})
+	require.Error(t, err)
+	_, err = parseHeaders([]string{"=value"})
+	require.Error(t, err)
+}
+
+func TestGRPCCredentials(t *testing.T) {
+	tlsConfig := nodeTransport{}.tlsConfig()
+
+	target, creds := grpcCredentials("localhost:9090", false, tlsConfig)
+	require.Equal(t, "localhost:9090", target)
+	require.Equal(t, "insecure", creds.Info().SecurityProtocol)
+
+	target, creds = grpcCredentials("localhost:9090", true, tlsConfig)
+	require.Equal(t, "localhost:9090", target)
+	require.Equal(t, "tls", creds.Info().SecurityProtocol)
+
+	target, creds = grpcCredentials("https://grpc.node.com:443", false, tlsConfig)
+	require.Equal(t, "grpc.node.com:443", target)
+	require.Equal(t, "tls", creds.Info().SecurityProtocol)
+}
+
+func TestHeaderTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	transport := headerTransport{
+		headers: map[string]string{"authorization": "Bearer token"},
+		next:    http.DefaultTransport,
+	}
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	// the headers are not sent in plaintext unless allowed
+	_, err = (&http.Client{Transport: transport}).Do(req)
+	require.Error(t, err)
+	require.True(t, headerCredentials{}.RequireTransportSecurity())
+	require.False(t, headerCredentials{insecure: true}.RequireTransportSecurity())
+
+	transport.insecure = true
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	require.Equal(t, "Bearer token", string(body[:n]))
+	// the request of the caller is not modified
+	require.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestConfig_nodeTransport(t *testing.T) {
+	conf := &Config{NodeTLS: true, GRPCHeaders: map[string]string{"authorization": "Bearer token"}}
+	transport, err := conf.nodeTransport()
+	require.NoError(t, err)
+	require.True(t, transport.tls)
+	require.Nil(t, transport.rootCAs)
+	require.Equal(t, conf.GRPCHeaders, transport.grpcHeaders)
+
+	conf.NodeCAFile = filepath.Join(t.TempDir(), "ca.pem")
+	_, err = conf.nodeTransport()
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(conf.NodeCAFile, []byte("not a certificate"), 0o600))
+	_, err = conf.nodeTransport()
+	require.Error(t, err)
+}
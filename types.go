@@ -2,6 +2,8 @@ package rosetta
 
 import (
 	"crypto/sha256"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
 // statuses
@@ -28,6 +30,25 @@ const (
 	// design we will never be able to query (as of now).
 	// Rosetta does not understand supply contraction.
 	BurnerAddressIdentifier = "burner"
+	// OperationFee is the type of the operations paying the fee of a transaction: the debit of the
+	// fee payer and the credit of the fee collector. Reverted transactions also pay their fee.
+	OperationFee = "fee"
+)
+
+// semantic types of the balance operations classified from the module accounts and events
+const (
+	// OperationMint is the type of the inflation minted to the mint module and sent to the fee collector
+	OperationMint = "mint"
+	// OperationReward is the type of the staking rewards and commission paid by the distribution module
+	OperationReward = "reward"
+	// OperationDelegate is the type of the tokens escrowed to the staking pools by a delegation
+	OperationDelegate = "delegate"
+	// OperationUnbond is the type of the tokens paid back to the delegator at the end of an unbonding
+	OperationUnbond = "unbond"
+	// OperationGovDeposit is the type of the deposits escrowed to the gov module
+	OperationGovDeposit = "gov_deposit"
+	// OperationGovRefund is the type of the deposits refunded by the gov module
+	OperationGovRefund = "gov_refund"
 )
 
 // TransactionType is used to distinguish if a rosetta provided hash
@@ -75,6 +96,26 @@ func (c *PreprocessOperationsOptionsResponse) FromMetadata(meta map[string]inter
 	return unmarshalMetadata(meta, c)
 }
 
+// TxMetadata is the metadata of the transactions returned by the data API, the execution
+// result fields are only set for delivered transactions
+type TxMetadata struct {
+	GasWanted     uint64    `json:"gas_wanted"`
+	GasUsed       *int64    `json:"gas_used,omitempty"`
+	Fee           sdk.Coins `json:"fee"`
+	FeePayer      string    `json:"fee_payer,omitempty"`
+	FeeGranter    string    `json:"fee_granter,omitempty"`
+	Memo          string    `json:"memo,omitempty"`
+	TimeoutHeight uint64    `json:"timeout_height,omitempty"`
+	Code          *uint32   `json:"code,omitempty"`
+	Codespace     string    `json:"codespace,omitempty"`
+	Log           string    `json:"log,omitempty"`
+	MessageTypes  []string  `json:"message_types"`
+}
+
+func (c TxMetadata) ToMetadata() (map[string]interface{}, error) {
+	return marshalMetadata(c)
+}
+
 // SignerData contains information on the signers when the request
 // is being created, used to populate the account information
 type SignerData struct {